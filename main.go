@@ -0,0 +1,7 @@
+package main
+
+import "github.com/harveyr/golintblame/pkg/lintblame"
+
+func main() {
+	lintblame.Main()
+}
@@ -0,0 +1,75 @@
+package lintblame
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Linters that check the file's whole package. -at only has the one file
+// at that revision, so they'd report the rest of the package as missing.
+var packageLinters = map[string]bool{
+	"gobuild": true, "govet": true, "gotest": true, "staticcheck": true,
+	"errcheck": true, "gosec": true, "golangci-lint": true,
+}
+
+// c.AtRev resolved in each repo, by root and revision. "" when the repo
+// doesn't have it.
+var atRevs = struct {
+	sync.Mutex
+	revs map[string]string
+}{revs: make(map[string]string)}
+
+// c.AtRev's full hash in the repo holding path. Each repo resolves it on
+// its own, so e.g. `HEAD~3` means something in every one of them.
+func (c *Config) atRevFor(path string) (string, error) {
+	root := gitRootFor(path)
+	if len(root) == 0 {
+		return "", fmt.Errorf("%s isn't in a git repo", path)
+	}
+	atRevs.Lock()
+	defer atRevs.Unlock()
+	key := root + "\x00" + c.AtRev
+	rev, ok := atRevs.revs[key]
+	if !ok {
+		out, err := exec.Command("git", "-C", root, "rev-parse", "--verify", c.AtRev+"^{commit}").Output()
+		if err != nil {
+			log.Printf("%s isn't a revision in %s; skipping its files", c.AtRev, root)
+		}
+		rev = strings.TrimSpace(string(out))
+		atRevs.revs[key] = rev
+	}
+	if len(rev) == 0 {
+		return "", fmt.Errorf("%s isn't a revision in %s", c.AtRev, root)
+	}
+	return rev, nil
+}
+
+// The file's content at rev. Errors if it didn't exist then.
+func showAtRev(path string, rev string) ([]byte, error) {
+	dir, file := filepath.Split(path)
+	cmd := exec.Command("git", "show", rev+":./"+file)
+	cmd.Dir = dir
+	return cmd.Output()
+}
+
+// Write content to a temp copy of the file for the linters to run against,
+// keeping the base name so tools that care about it (go build, pylint's
+// module names) behave the same. Returns the temp dir to clean up.
+func (tf *TargetFile) lintCopy(content []byte) (string, error) {
+	dir, err := ioutil.TempDir("", "lintblame")
+	if err != nil {
+		return "", err
+	}
+	tf.LintPath = filepath.Join(dir, filepath.Base(tf.Path))
+	if err := ioutil.WriteFile(tf.LintPath, content, 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
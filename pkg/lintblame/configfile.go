@@ -0,0 +1,126 @@
+package lintblame
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Name of the per-project settings file, found by walking up from the
+// working dir
+const configFileName = ".lintblame.json"
+
+// Flags that decide which files are linted, and so where to look for the
+// settings file, so it can't set them
+var commandLineOnly = map[string]bool{"b": true, "stdin": true}
+
+// The settings file's key for regex-parsed linters of your own, which has
+// no flag
+const customLintersKey = "custom-linters"
+
+// Find the nearest settings file at or above dir, or "" if there isn't one
+func findConfigFile(dir string) string {
+	for {
+		path := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Apply the nearest settings file's values for any flags that weren't
+// passed, and register its custom linters. Keys are flag names, e.g.
+//
+//	{"base": "main", "linters": ["pylint", "gobuild"], "jobs": 4,
+//	 "exclude": ["*_test.go"], "linter-exit-codes": {"pylint": [0, 4]}}
+func loadConfigFile(dir string, passed map[string]bool) {
+	path := findConfigFile(dir)
+	if len(path) == 0 {
+		return
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatal("Failed to read ", path, ": ", err)
+	}
+	settings := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&settings); err != nil {
+		fatal("Bad ", path, ": ", err)
+	}
+	if _, ok := settings[customLintersKey]; ok {
+		registerCustomLinters(content, path)
+		delete(settings, customLintersKey)
+	}
+	for name, value := range settings {
+		if flag.Lookup(name) == nil {
+			fatalf("Unknown setting %s in %s", name, path)
+		} else if commandLineOnly[name] {
+			fatalf("-%s can't be set in %s", name, path)
+		}
+		if passed[name] {
+			continue
+		}
+		if err := flag.Set(name, flagValue(value)); err != nil {
+			fatalf("Bad %s in %s: %s", name, path, err)
+		}
+	}
+	debugf("Using settings from %s", path)
+}
+
+// Add the settings file's custom linters to the registry
+func registerCustomLinters(content []byte, path string) {
+	var file struct {
+		CustomLinters []customLinterSpec `json:"custom-linters"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		fatalf("Bad %s in %s: %s", customLintersKey, path, err)
+	}
+	for _, spec := range file.CustomLinters {
+		linter, err := newRegexLinter(spec)
+		if err != nil {
+			fatalf("Bad %s in %s: %s", customLintersKey, path, err)
+		}
+		linters = append(linters, linter)
+		customLinters = append(customLinters, linter.Name())
+	}
+}
+
+// The names of the settings file's custom linters
+var customLinters []string
+
+// Turn a settings file value into what the flag would be passed: lists
+// are comma-separated, and maps of lists are `key=1:2,other=3`
+func flagValue(value interface{}) string {
+	switch value := value.(type) {
+	case []interface{}:
+		parts := make([]string, len(value))
+		for i, item := range value {
+			parts[i] = flagValue(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key + "=" + strings.Replace(flagValue(value[key]), ",", ":", -1)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,90 @@
+package lintblame
+
+import (
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A `git diff -U0` hunk header, e.g. `@@ -12,3 +14,5 @@`. The new side's
+// count is left out when it's 1.
+var hunkHeader = regexp.MustCompile(`(?m)^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// The new side's line numbers that `git diff -U0` output adds or changes
+func parseDiffLines(out string) map[int]bool {
+	changed := make(map[int]bool)
+	for _, group := range hunkHeader.FindAllStringSubmatch(out, -1) {
+		start, _ := strconv.Atoi(group[1])
+		count := 1
+		if len(group[2]) > 0 {
+			count, _ = strconv.Atoi(group[2])
+		}
+		// A count of 0 is a pure deletion, which leaves no line to blame
+		for line := start; line < start+count; line++ {
+			changed[line] = true
+		}
+	}
+	return changed
+}
+
+// Record which lines differ from the repo's diffBase, for -diff. They're left
+// nil, so that every line counts as changed, when git can't say, e.g. for
+// files it doesn't track yet.
+func (tf *TargetFile) Diff() {
+	root := gitRootFor(tf.Path)
+	if len(root) == 0 || len(tf.Blames) == 0 {
+		return
+	}
+	cmd := tf.command("git", "-C", root, "diff", "-U0", "--no-color", "--no-ext-diff", tf.config.diffBase(root), "--", tf.Path)
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	tf.ChangedLines = parseDiffLines(string(out))
+}
+
+// The lines that differ between the working tree and the index, so
+// uncommitted lines that aren't among them are staged. Nil when git can't
+// say.
+func (tf *TargetFile) unstagedLines(root string) map[int]bool {
+	cmd := tf.command("git", "-C", root, "diff", "-U0", "--no-color", "--no-ext-diff", "--", tf.Path)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return parseDiffLines(string(out))
+}
+
+// Cache of diffBase, by repo root and base branch
+var diffBases = struct {
+	sync.Mutex
+	byRoot map[string]string
+}{byRoot: make(map[string]string)}
+
+// The commit -diff compares the repo at root against: where the branch left
+// the base branch with -b, else HEAD, so uncommitted changes are what's
+// shown. That's also the fallback when there's no base branch to compare
+// against.
+func (c *Config) diffBase(root string) string {
+	if !c.BranchMode || len(c.BaseBranch) == 0 {
+		return "HEAD"
+	}
+	diffBases.Lock()
+	defer diffBases.Unlock()
+	key := root + "\x00" + c.BaseBranch
+	if base, ok := diffBases.byRoot[key]; ok {
+		return base
+	}
+	base := "HEAD"
+	out, err := exec.Command("git", "-C", root, "merge-base", c.BaseBranch, "HEAD").Output()
+	if err != nil {
+		log.Printf("Failed to find where the branch left %s in %s, showing uncommitted changes", c.BaseBranch, root)
+	} else {
+		base = strings.TrimSpace(string(out))
+	}
+	diffBases.byRoot[key] = base
+	return base
+}
@@ -0,0 +1,114 @@
+package lintblame
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A linter's fix mode, which rewrites the file in place
+type Fixer struct {
+	Name       string
+	Exts       []string
+	Binary     string
+	Args       []string
+	Reporters  []string // Whose warts it fixes
+	LineLength string   // Its flag for -max-line-length, if it has one
+}
+
+// Fixers that only make changes their linter considers safe
+var fixers = []Fixer{
+	{Name: "gofmt", Exts: goExts, Binary: "gofmt", Args: []string{"-w"}, Reporters: []string{"gofmt"}},
+	{Name: "goimports", Exts: goExts, Binary: "goimports", Args: []string{"-w"}, Reporters: []string{"gofmt", "build"}},
+	{Name: "ruff", Exts: pyExts, Binary: "ruff", Args: []string{"check", "--fix", "--quiet"}, Reporters: []string{"flake8", "pyflakes"}, LineLength: "--line-length"},
+	{Name: "autopep8", Exts: pyExts, Binary: "autopep8", Args: []string{"--in-place"}, Reporters: []string{"PEP8", "flake8"}, LineLength: "--max-line-length"},
+	{Name: "isort", Exts: pyExts, Binary: "isort", Args: []string{"--quiet"}, Reporters: []string{"isort"}, LineLength: "--line-length"},
+	{Name: "black", Exts: pyExts, Binary: "black", Args: []string{"--quiet"}, Reporters: []string{"black"}, LineLength: "--line-length"},
+	{Name: "eslint", Exts: jsExts, Binary: "eslint", Args: []string{"--fix"}, Reporters: []string{"eslint"}},
+}
+
+// Whether the file has warts the fixer is meant to fix, from linters that
+// are enabled, or they wouldn't have run
+func (f Fixer) wanted(tf *TargetFile) bool {
+	for _, warts := range tf.Warts {
+		for _, wart := range warts {
+			for _, reporter := range f.Reporters {
+				if wart.Reporter == reporter {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// What each file looked like after we last fixed it, so our own rewrites
+// don't count as uncommitted changes
+var fixedContent = struct {
+	sync.Mutex
+	sums map[string][sha256.Size]byte
+}{sums: make(map[string][sha256.Size]byte)}
+
+// Whether git reports uncommitted changes to the file. Files git can't
+// vouch for count as dirty.
+func isDirty(path string) bool {
+	dir, file := filepath.Split(path)
+	cmd := exec.Command("git", "status", "--porcelain", "--", file)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return err != nil || len(bytes.TrimSpace(out)) > 0
+}
+
+// Run the fixers for the file's warts against it, recording which ones
+// rewrote it. Returns whether any did.
+func (tf *TargetFile) Fix() bool {
+	content, err := ioutil.ReadFile(tf.Path)
+	if err != nil {
+		return false
+	}
+	fixedContent.Lock()
+	lastFixed, ok := fixedContent.sums[tf.Path]
+	fixedContent.Unlock()
+	ourChanges := ok && lastFixed == sha256.Sum256(content)
+	if !tf.config.FixDirty && !ourChanges && isDirty(tf.Path) {
+		tf.FixSkipped = "uncommitted changes, use -fix-dirty to fix anyway"
+		return false
+	}
+	for _, fixer := range fixers {
+		if !hasExt(fixer.Exts, filepath.Ext(tf.Path)) || !fixer.wanted(tf) || !haveBinary(fixer.Binary) {
+			continue
+		}
+		args := append(append([]string{}, fixer.Args...), tf.config.lineLengthArgs(fixer.LineLength)...)
+		cmd := tf.command(fixer.Binary, append(args, tf.Path)...)
+		cmd.Run()
+		after, err := ioutil.ReadFile(tf.Path)
+		if err != nil {
+			break
+		}
+		if !bytes.Equal(content, after) {
+			tf.Fixed = append(tf.Fixed, fixer.Name)
+			content = after
+		}
+	}
+	if len(tf.Fixed) > 0 {
+		fixedContent.Lock()
+		fixedContent.sums[tf.Path] = sha256.Sum256(content)
+		fixedContent.Unlock()
+	}
+	return len(tf.Fixed) > 0
+}
+
+// Describe what -fix did to the file, if anything
+func fixSummary(tf *TargetFile) string {
+	if len(tf.FixSkipped) > 0 {
+		return color("dim", fmt.Sprintf("[not fixed: %s]", tf.FixSkipped))
+	} else if len(tf.Fixed) > 0 {
+		return color("green", fmt.Sprintf("[fixed by %s]", strings.Join(tf.Fixed, ", ")))
+	}
+	return ""
+}
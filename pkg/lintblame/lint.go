@@ -0,0 +1,120 @@
+package lintblame
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// What Lint runs. The zero value runs the default linters over the files
+// directly in each path, with as many files at once as there are CPUs.
+type Options struct {
+	Linters       []string // By name, as -linters takes them
+	Recursive     bool     // Include files in subdirectories, like -r
+	Include       []string // Globs a file must match one of, if any
+	Exclude       []string
+	NoBlame       bool
+	Jobs          int           // Files linted at once
+	LinterTimeout time.Duration // 0 for no limit
+	MaxLineLength int           // 0 for each linter's own
+}
+
+// The configuration that lints the paths with the options, the way the
+// lintblame command does without a settings file
+func (opts Options) config(paths []string) (*Config, error) {
+	enabled := make(map[string]bool)
+	for _, name := range opts.Linters {
+		if lookupLinter(name) == nil {
+			return nil, fmt.Errorf("unknown linter %s", name)
+		}
+		enabled[name] = true
+	}
+	if len(opts.Linters) == 0 {
+		// The command's defaults, which run pep8 and pylint for Python
+		for _, name := range defaultLinters() {
+			enabled[name] = true
+		}
+		for _, linters := range pyLinterSets {
+			for _, name := range linters {
+				enabled[name] = false
+			}
+		}
+		for _, name := range pyLinterSets[defaultPyLinter] {
+			enabled[name] = true
+		}
+	}
+	jobs := opts.Jobs
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	} else if jobs < 0 {
+		return nil, fmt.Errorf("jobs must be at least 1")
+	}
+	if opts.MaxLineLength < 0 {
+		return nil, fmt.Errorf("max line length can't be negative")
+	}
+	for _, glob := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %s: %s", glob, err)
+		}
+	}
+	exitCodes, err := parseExitCodes("")
+	if err != nil {
+		return nil, err
+	}
+
+	c := Config{
+		Linters:       enabled,
+		ExitCodes:     exitCodes,
+		Recursive:     opts.Recursive,
+		Include:       opts.Include,
+		Exclude:       opts.Exclude,
+		NoBlame:       opts.NoBlame,
+		Jobs:          jobs,
+		LinterJobs:    1,
+		LinterTimeout: opts.LinterTimeout,
+		MaxLineLength: opts.MaxLineLength,
+		CycloMax:      15,
+		SeverityMin:   SeverityInfo,
+		Order:         "arrival",
+		GroupBy:       "line",
+		Format:        "text",
+		NoColor:       true,
+		Once:          true,
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	workingDirs := make([]string, len(paths))
+	for i, path := range paths {
+		argPath, workingDir, err := resolveArgPath(path)
+		if err != nil {
+			return nil, err
+		}
+		c.ArgPaths = append(c.ArgPaths, argPath)
+		workingDirs[i] = workingDir
+	}
+	c.WorkingDir = commonDir(workingDirs)
+	return &c, nil
+}
+
+// Lint the files and directories at paths once, the way the lintblame
+// command does without a settings file, and return the files sorted by
+// path. Relative paths are relative to the working directory. Problems
+// with the paths or options are returned; a linter that falls over is a
+// lintblame wart on the file, as it is for the command.
+func Lint(paths []string, opts Options) ([]*TargetFile, error) {
+	c, err := opts.config(paths)
+	if err != nil {
+		return nil, err
+	}
+	filepaths, err := c.targetPaths()
+	if err != nil {
+		return nil, err
+	}
+	files := receiveFiles(lintFiles(context.Background(), c, filepaths), len(filepaths))
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
@@ -0,0 +1,3039 @@
+package lintblame
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var colors = map[string]string{
+	"header": "\033[95m",
+	"blue":   "\033[94m",
+	"green":  "\033[92m",
+	"yellow": "\033[93m",
+	"red":    "\033[91m",
+	"bold":   "\033[1m",
+	"dim":    "\033[2m",
+	"end":    "\033[0m",
+}
+
+func color(color string, s string) string {
+	if config.NoColor {
+		return s
+	}
+	return fmt.Sprintf("%s%s%s", colors[color], s, colors["end"])
+}
+
+var rexes = map[string]*regexp.Regexp{
+	"pep8":        regexp.MustCompile(`\w+:(\d+):(\d+):\s(\w+)\s(.+)(?m)$`),
+	"pylint":      regexp.MustCompile(`(?m)^(\d+),(\d+):([A-Z]\d+):(.+)$`),
+	"goBuild":     regexp.MustCompile(`(?m)^.+?\.go:(\d+)(?::(\d+))?:\s(.+)$`),
+	"golint":      regexp.MustCompile(`(?m)^.+?:(\d+):(\d+):\s(.+)$`),
+	"pyflakes":    regexp.MustCompile(`(?m)^.+?:(\d+):(?:(\d+):)?\s(.+)$`),
+	"flake8":      regexp.MustCompile(`(?m)^.+?:(\d+):(\d+):\s(\w+)\s(.+)$`),
+	"mypy":        regexp.MustCompile(`(?m)^.+?:(\d+):(\d+):\s(?:error|warning):\s(.+?)(?:\s+\[([\w-]+)\])?$`),
+	"errcheck":    regexp.MustCompile(`(?m)^(.+?):(\d+):(\d+):\s+(.+)$`),
+	"staticcheck": regexp.MustCompile(`(?m)^(.+?):(\d+):(\d+):\s(.+)\s\((\w+)\)$`),
+	"ineffassign": regexp.MustCompile(`(?m)^(.+?):(\d+):(\d+):\s(ineffectual assignment to .+)$`),
+	"gocyclo":     regexp.MustCompile(`(?m)^(\d+)\s(\S+)\s(\S+)\s.+:(\d+):(\d+)$`),
+	// `go test` names each failing test, then indents what it logged under
+	// it, e.g. `    parse_test.go:12: got 2, want 1`
+	"goTestFail": regexp.MustCompile(`^\s*--- FAIL: (\S+)`),
+	"goTestLog":  regexp.MustCompile(`^\s+(\S+\.go):(\d+): (.+)$`),
+	// Many of golangci-lint's linters lead with their rule, e.g.
+	// `SA4006: this value of err is never used`
+	"golangciRule": regexp.MustCompile(`^([\w-]+): (.+)$`),
+}
+
+type Config struct {
+	BranchMode       bool
+	BaseBranch       string
+	WorkingDir       string
+	ArgPaths         []string
+	Stdin            bool // Whether the paths came from stdin
+	StdinPaths       []string
+	InitialPaths     []string
+	PrintLimit       int
+	SeverityMin      Severity
+	Ignore           map[string]bool // `reporter:code` pairs, with the reporter lowercased
+	Lines            []lineRange     // With -lines, the only lines to show warts on
+	ShowLinters      bool
+	GitRootPaths     bool
+	AbsPaths         bool
+	TUI              bool
+	SinceCommit      string
+	DiffOnly         bool
+	GoVetFlags       []string
+	Order            string
+	OrderDir         string
+	Jobs             int
+	LinterJobs       int
+	LinterTimeout    time.Duration
+	GroupConsecutive bool
+	Context          int // Lines of source to show around each wart line
+	GroupBy          string
+	Fix              bool
+	FixDirty         bool
+	RepeatHeader     int
+	AtRev            string
+	LabelUnstaged    bool
+	NoBlame          bool
+	Linters          map[string]bool // Enabled linters
+	Format           string
+	NoFooter         bool
+	Clear            bool                    // Clear the screen before each run's text output
+	ExitCodes        map[string]map[int]bool // Per linter, statuses that mean it ran
+	Quiet            bool                    // Leave clean files out
+	Profile          bool
+	Stats            bool // Only print the summary's counts
+	QuietClean       bool
+	Bell             bool
+	Me               string
+	Deadline         time.Duration
+	Once             bool
+	Recursive        bool
+	Include          []string // Globs a file must match one of, if any
+	Exclude          []string
+	NoColor          bool
+	CycloMax         int
+	MaxLineLength    int // Passed on to the linters that check it, unless 0
+	Interval         time.Duration
+	Verbose          bool
+}
+
+var config = Config{}
+
+type Environment struct {
+	gitPath    string
+	gitPathErr error
+	gitName    string
+}
+
+// Find the git root, remembering failure so we don't keep asking git
+func (c *Environment) GitRoot() (string, error) {
+	if len(c.gitPath) == 0 && c.gitPathErr == nil {
+		cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+		out, err := cmd.Output()
+		if err != nil {
+			c.gitPathErr = err
+		} else {
+			c.gitPath = strings.TrimSpace(string(out))
+		}
+	}
+	return c.gitPath, c.gitPathErr
+}
+
+func (c *Environment) GitName() string {
+	if len(c.gitName) == 0 {
+		cmd := exec.Command("git", "config", "user.name")
+		out, err := cmd.Output()
+		if err != nil {
+			c.gitName = "None"
+		} else {
+			c.gitName = strings.TrimSpace(string(out))
+		}
+	}
+	return c.gitName
+}
+
+// The branch checked out in dir's repo, or "HEAD" when it's detached. Fails
+// in a repo with no commits yet.
+func (c Environment) CurrentGitBranch(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("no current branch: %s", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+var env = Environment{}
+
+// Git roots by directory, since targets can span several repos
+var gitRoots = struct {
+	sync.Mutex
+	byDir map[string]string
+}{byDir: make(map[string]string)}
+
+// The root of the repo holding the file, or "" if it isn't in one
+func gitRootFor(path string) string {
+	dir := filepath.Dir(path)
+	gitRoots.Lock()
+	defer gitRoots.Unlock()
+	if root, ok := gitRoots.byDir[dir]; ok {
+		return root
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err == nil {
+		gitRoots.byDir[dir] = strings.TrimSpace(string(out))
+	}
+	return gitRoots.byDir[dir]
+}
+
+// Whether a blame name is the user's, per -me or else git's user.name.
+// Changes that are still in the working tree or index are always yours.
+func (c *Config) isMe(blameName string) bool {
+	if blameName == stagedName || blameName == unstagedName {
+		return true
+	}
+	if len(c.Me) > 0 {
+		return blameName == c.Me
+	}
+	return blameName == env.GitName()
+}
+
+// Cache of exec.LookPath results, shared by the linter goroutines
+var binaries = struct {
+	sync.Mutex
+	found map[string]bool
+}{found: make(map[string]bool)}
+
+// Whether an executable is available on PATH
+func haveBinary(name string) bool {
+	binaries.Lock()
+	defer binaries.Unlock()
+	if found, ok := binaries.found[name]; ok {
+		return found
+	}
+	_, err := exec.LookPath(name)
+	binaries.found[name] = err == nil
+	return binaries.found[name]
+}
+
+type Times []time.Time
+
+func (s Times) Len() int      { return len(s) }
+func (s Times) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// Paths sorted by their Times, oldest first. Paths modified at the same
+// time are sorted by name so the order doesn't change from run to run.
+type ByTime struct {
+	Times
+	Paths []string
+}
+
+func (s ByTime) Less(i, j int) bool {
+	if s.Times[i].Equal(s.Times[j]) {
+		return s.Paths[i] < s.Paths[j]
+	}
+	return s.Times[i].Before(s.Times[j])
+}
+
+func (s ByTime) Swap(i, j int) {
+	s.Times.Swap(i, j)
+	s.Paths[i], s.Paths[j] = s.Paths[j], s.Paths[i]
+}
+
+type ModifiedTimes struct {
+	TimeMap map[string]time.Time
+}
+
+func (m *ModifiedTimes) CheckTime(path string) bool {
+	hasChanged := false
+	fileInfo, err := os.Stat(path)
+	if err == nil {
+		if fileInfo != nil {
+			mt := fileInfo.ModTime()
+			if storedTime, ok := m.TimeMap[path]; ok {
+				if !storedTime.Equal(mt) {
+					hasChanged = true
+				}
+			} else {
+				hasChanged = true
+			}
+			if hasChanged {
+				m.TimeMap[path] = mt
+			}
+		}
+	}
+	return hasChanged
+}
+
+// Order the paths from oldest to most recently modified, so that printing
+// them in order puts the most recent file at the end of the output, next to
+// the prompt, where it's most visible
+func (m ModifiedTimes) SortaSorted() []string {
+	byTime := ByTime{
+		Times: make(Times, 0, len(m.TimeMap)),
+		Paths: make([]string, 0, len(m.TimeMap)),
+	}
+	for path, modTime := range m.TimeMap {
+		byTime.Times = append(byTime.Times, modTime)
+		byTime.Paths = append(byTime.Paths, path)
+	}
+	sort.Sort(byTime)
+	return byTime.Paths
+}
+
+func (m ModifiedTimes) Len() int {
+	return len(m.TimeMap)
+}
+
+// Record the modified times of the files c targets
+func NewModifiedTimes(c *Config) (*ModifiedTimes, error) {
+	modTimes := ModifiedTimes{TimeMap: make(map[string]time.Time)}
+	paths, err := c.targetPaths()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range paths {
+		modTimes.CheckTime(file)
+	}
+	return &modTimes, nil
+}
+
+type Wart struct {
+	Reporter  string
+	Line      int
+	Column    int
+	IssueCode string
+	Message   string
+	Severity  Severity
+}
+
+func (w Wart) String() string {
+	return fmt.Sprintf("%d: [%s %s] %s", w.Line, w.Reporter, w.IssueCode, w.Message)
+}
+
+// The message, with the column when the linter reported one
+func (w Wart) Detail() string {
+	if w.Column > 0 {
+		return fmt.Sprintf("%s (col %d)", w.Message, w.Column)
+	}
+	return w.Message
+}
+
+func NewWart(reporter string, line string, column string, issueCode string, message string) (Wart, error) {
+
+	line64, err := strconv.ParseInt(line, 10, 0)
+	if err != nil {
+		return Wart{}, fmt.Errorf("failed parsing line number %s", line)
+	}
+	col64, err := strconv.ParseInt(column, 10, 0)
+	if err != nil {
+		return Wart{}, fmt.Errorf("failed parsing column number %s", column)
+	}
+	w := Wart{
+		Reporter:  reporter,
+		Line:      int(line64),
+		Column:    int(col64),
+		IssueCode: issueCode,
+		Message:   message,
+		Severity:  severityFor(reporter, issueCode),
+	}
+	return w, nil
+}
+
+// Records whether a linter ran against a file, and why not if it didn't
+type LinterStatus struct {
+	Name     string
+	Ran      bool
+	Reason   string
+	Duration time.Duration // How long it took, when it ran
+}
+
+// Who last touched a line, and in which commit
+type BlameInfo struct {
+	Commit string
+	Name   string
+	Date   time.Time // When the line was authored
+}
+
+// Commits git blame reports for lines that haven't been committed
+const uncommittedHash = "00000000"
+
+// Whether the line hasn't been committed yet
+func (b BlameInfo) Uncommitted() bool {
+	return strings.HasPrefix(b.Commit, uncommittedHash)
+}
+
+type TargetFile struct {
+	Path         string
+	LintPath     string // What the linters run against, when not Path
+	ContentLines []string
+	Blames       map[int]BlameInfo // By line number
+	ChangedLines map[int]bool      // With -diff, lines that differ from diffBase
+	Unstaged     map[int]bool      // With -label-unstaged, lines that differ from the index
+	Warts        map[int][]Wart
+	Linters      []LinterStatus
+	Fixed        []string // Fixers that rewrote the file
+	FixSkipped   string   // Why -fix left the file alone
+
+	// What it's linted with
+	config *Config
+	// With -at, the revision's full hash in the file's repo
+	atRev string
+	// Guards Warts and Linters while linters run concurrently
+	lock sync.Mutex
+	// Cancelled when the run's deadline passes
+	ctx context.Context
+}
+
+// Build a command that's killed if the file's context is cancelled
+func (tf *TargetFile) command(name string, args ...string) *exec.Cmd {
+	if tf.ctx == nil {
+		return exec.Command(name, args...)
+	}
+	return exec.CommandContext(tf.ctx, name, args...)
+}
+
+func (tf *TargetFile) Blame() {
+	root := gitRootFor(tf.Path)
+	if len(root) == 0 {
+		root = tf.config.WorkingDir
+	}
+	args := []string{"-C", root, "blame", "--line-porcelain"}
+	if len(tf.atRev) > 0 {
+		args = append(args, tf.atRev)
+	}
+	args = append(args, "--", tf.Path)
+	results, err := gitRetry(func() *exec.Cmd { return tf.command("git", args...) })
+	if err != nil {
+		tf.Blames = make(map[int]BlameInfo)
+		return
+	}
+	tf.Blames = parseBlame(string(results))
+	if tf.config.LabelUnstaged {
+		tf.Unstaged = tf.unstagedLines(root)
+	}
+}
+
+// Parse `git blame --line-porcelain` output. Each line gets a header
+// starting with its commit and line number, then key-value lines like
+// `author Jane Doe`, then the line itself prefixed by a tab.
+func parseBlame(out string) map[int]BlameInfo {
+	blames := make(map[int]BlameInfo)
+	var blame BlameInfo
+	line := 0
+	inHeader := false
+	for _, text := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			if inHeader {
+				blames[line] = blame
+			}
+			inHeader = false
+		case !inHeader:
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				continue
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+			blame = BlameInfo{Commit: fields[0]}
+			line = n
+			inHeader = true
+		case strings.HasPrefix(text, "author "):
+			blame.Name = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				blame.Date = time.Unix(seconds, 0)
+			}
+		}
+	}
+	return blames
+}
+
+// Get the blame info for a given line, if git blamed it
+func (tf *TargetFile) BlameFor(line int) (BlameInfo, bool) {
+	blame, ok := tf.Blames[line]
+	return blame, ok
+}
+
+// The source of a line, or a placeholder when a linter reports a line
+// that isn't in the file, e.g. one past the end
+func (tf *TargetFile) ContentLine(line int) string {
+	if line < 1 || line > len(tf.ContentLines) {
+		return "[line not in file]"
+	}
+	return tf.ContentLines[line-1]
+}
+
+func (tf *TargetFile) ExtEquals(ext string) bool {
+	return filepath.Ext(tf.Path) == ext
+}
+
+// Linters that can be picked with -linters or switched off on their own,
+// e.g. -pylint=false
+var linterNames = func() []string {
+	names := make([]string, len(linters))
+	for i, linter := range linters {
+		names[i] = linter.Name()
+	}
+	return names
+}()
+
+// Linters left out of the default -linters list. Slow, or only useful to
+// projects that are set up for them.
+var optInLinters = map[string]bool{
+	"mypy": true, "gosec": true, "gotest": true, "golangci-lint": true, "bandit": true, "black": true, "isort": true,
+}
+
+// The linters -linters defaults to
+func defaultLinters() []string {
+	names := make([]string, 0, len(linterNames))
+	for _, name := range linterNames {
+		if !optInLinters[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Whether a linter runs when neither -linters, -py-linter nor its own
+// toggle says otherwise
+func linterDefault(name string) bool {
+	if optInLinters[name] {
+		return false
+	}
+	for choice, names := range pyLinterSets {
+		for _, n := range names {
+			if n == name {
+				return choice == defaultPyLinter
+			}
+		}
+	}
+	return true
+}
+
+// The -py-linter choice used when it isn't passed
+const defaultPyLinter = "pep8+pylint"
+
+// The Python linters each -py-linter choice runs. flake8 wraps pep8's
+// checks, so it replaces the pair rather than joining them.
+var pyLinterSets = map[string][]string{
+	"pep8+pylint": {"pep8", "pylint"},
+	"flake8":      {"flake8"},
+	"pyflakes":    {"pyflakes"},
+}
+
+// Check whether a linter is enabled, applies to the file, and is
+// installed, recording the outcome either way
+func (tf *TargetFile) canRun(linter Linter) bool {
+	status := LinterStatus{Name: linter.Name()}
+	ext := filepath.Ext(tf.Path)
+	if !tf.config.Linters[linter.Name()] {
+		status.Reason = "disabled"
+	} else if !linter.Applies(ext) {
+		status.Reason = "not for " + ext
+	} else if filter, ok := linter.(fileFilter); ok && !filter.AppliesTo(tf.Path) {
+		status.Reason = "not for " + filepath.Base(tf.Path)
+	} else if len(tf.config.AtRev) > 0 && packageLinters[linter.Name()] {
+		status.Reason = "checks the package, -at has only the file"
+	} else if !haveBinary(linter.Binary()) {
+		status.Reason = "not installed"
+	} else {
+		status.Ran = true
+	}
+	tf.lock.Lock()
+	tf.Linters = append(tf.Linters, status)
+	tf.lock.Unlock()
+	return status.Ran
+}
+
+// Record how long a linter that ran took
+func (tf *TargetFile) timed(name string, duration time.Duration) {
+	tf.lock.Lock()
+	defer tf.lock.Unlock()
+	for i, status := range tf.Linters {
+		if status.Name == name && status.Ran {
+			tf.Linters[i].Duration = duration
+		}
+	}
+}
+
+// Record that a linter we thought could run turned out not to be installed
+func (tf *TargetFile) notInstalled(name string) {
+	tf.lock.Lock()
+	defer tf.lock.Unlock()
+	for i, status := range tf.Linters {
+		if status.Name == name {
+			tf.Linters[i] = LinterStatus{Name: name, Reason: "not installed"}
+		}
+	}
+}
+
+// Summarize which linters ran, e.g. `[ran: pep8; skipped: pylint (not installed)]`
+func (tf *TargetFile) LinterSummary() string {
+	ran := make([]string, 0)
+	skipped := make([]string, 0)
+	for _, status := range tf.Linters {
+		if status.Ran {
+			ran = append(ran, status.Name)
+		} else {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", status.Name, status.Reason))
+		}
+	}
+	parts := make([]string, 0, 2)
+	if len(ran) > 0 {
+		parts = append(parts, "ran: "+strings.Join(ran, ", "))
+	}
+	if len(skipped) > 0 {
+		parts = append(parts, "skipped: "+strings.Join(skipped, ", "))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, "; "))
+}
+
+// Add a wart, unless the line already has the same one: the same code,
+// message and column, whichever linter reported it. Of two linters
+// reporting the same thing, the more severe copy is kept.
+func (tf *TargetFile) AddWart(wart Wart) {
+	if tf.ignored(wart) {
+		return
+	}
+	tf.lock.Lock()
+	defer tf.lock.Unlock()
+	if _, ok := tf.Warts[wart.Line]; !ok {
+		tf.Warts[wart.Line] = make([]Wart, 0)
+	}
+	for i, existing := range tf.Warts[wart.Line] {
+		if existing.IssueCode == wart.IssueCode && existing.Message == wart.Message && existing.Column == wart.Column {
+			if wart.Severity > existing.Severity {
+				tf.Warts[wart.Line][i] = wart
+			}
+			return
+		}
+	}
+	tf.Warts[wart.Line] = append(tf.Warts[wart.Line], wart)
+}
+
+// Parse -ignore, e.g. `pep8:E501,Pylint:C0111`. Reporters match whatever the
+// case.
+func parseIgnore(spec string) (map[string]bool, error) {
+	ignore := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("expected reporter:code, got %s", entry)
+		}
+		ignore[strings.ToLower(parts[0])+":"+parts[1]] = true
+	}
+	return ignore, nil
+}
+
+// Whether -ignore drops the wart
+func (tf *TargetFile) ignored(wart Wart) bool {
+	return tf.config.Ignore[strings.ToLower(wart.Reporter)+":"+wart.IssueCode]
+}
+
+// The reporters named in -ignore that no linter tags its warts with, in
+// order
+func (c *Config) unknownReporters() []string {
+	unknown := make(map[string]bool)
+	for entry := range c.Ignore {
+		reporter := strings.SplitN(entry, ":", 2)[0]
+		if !c.knownReporter(reporter) {
+			unknown[reporter] = true
+		}
+	}
+	reporters := make([]string, 0, len(unknown))
+	for reporter := range unknown {
+		reporters = append(reporters, reporter)
+	}
+	sort.Strings(reporters)
+	return reporters
+}
+
+// Which of a linter's output streams its findings are written to
+type outputStream int
+
+const (
+	stdoutStream outputStream = iota
+	stderrStream
+	// Both, interleaved. The linter's regexp picks the findings out of any
+	// progress noise.
+	combinedStreams
+)
+
+// What lintOutput returns when a linter runs past -linter-timeout
+var errLinterTimeout = errors.New("timed out")
+
+// A copy of cmd that's killed once ctx is done
+func withContext(ctx context.Context, cmd *exec.Cmd) *exec.Cmd {
+	c := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+	c.Args = cmd.Args
+	c.Dir, c.Env, c.Stdin = cmd.Dir, cmd.Env, cmd.Stdin
+	return c
+}
+
+// Run a linter and return the output its findings are written to, the
+// other stream if there is one, and the error from running it. Linters
+// that run past the timeout, unless it's 0, are killed.
+func lintOutput(ctx context.Context, cmd *exec.Cmd, stream outputStream, timeout time.Duration) (string, string, error) {
+	timeoutCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		cmd = withContext(timeoutCtx, cmd)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stream == combinedStreams {
+		cmd.Stderr = &stdout
+	}
+	// Don't wait on the output of anything it started that outlives it
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	// Only our deadline counts as a timeout, not the caller giving up
+	if err != nil && timeoutCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		err = errLinterTimeout
+	}
+	if stream == stderrStream {
+		return stderr.String(), stdout.String(), err
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// Exit statuses that mean a linter ran and reported its findings, as
+// opposed to falling over. Overridden per linter with -linter-exit-codes.
+var defaultExitCodes = map[string][]int{
+	"pep8":   {0, 1},
+	"flake8": {0, 1},
+	"mypy":   {0, 1},
+	"black":  {0, 1}, // 1 means it would reformat
+	"isort":  {0, 1}, // 1 means it would reorder
+	"eslint": {0, 1},
+	// 1 means it found offenses, 2 that it fell over
+	"rubocop": {0, 1},
+	// Pylint ORs together a bit per message category. 1 is fatal and 32 is
+	// a usage error.
+	"pylint":  {0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30},
+	"gobuild": {0, 1},
+	"govet":   {0, 1},
+	"golint":  {0},
+	"gofmt":   {0},
+	// 1 means they found something
+	"staticcheck": {0, 1},
+	"errcheck":    {0, 1},
+	"pyflakes":    {0, 1},
+	"gosec":       {0, 1},
+	"bandit":      {0, 1},
+	"gocyclo":     {0, 1},
+	"gotest":      {0, 1},
+	// 1 means it found issues
+	"golangci-lint": {0, 1},
+	// Newer versions are analysis drivers, which exit 3 on findings
+	"ineffassign": {0, 1, 3},
+}
+
+// Parse -linter-exit-codes, e.g. `pylint=0:4:16,pep8=0:1`, over the
+// defaults
+func parseExitCodes(spec string) (map[string]map[int]bool, error) {
+	exitCodes := make(map[string]map[int]bool)
+	for name, codes := range defaultExitCodes {
+		exitCodes[name] = make(map[int]bool)
+		for _, code := range codes {
+			exitCodes[name][code] = true
+		}
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		if len(strings.TrimSpace(entry)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected linter=codes, got %s", entry)
+		}
+		name := strings.TrimSpace(parts[0])
+		exitCodes[name] = make(map[int]bool)
+		for _, code := range strings.Split(parts[1], ":") {
+			n, err := strconv.Atoi(strings.TrimSpace(code))
+			if err != nil {
+				return nil, fmt.Errorf("bad exit code %s for %s", code, name)
+			}
+			exitCodes[name][n] = true
+		}
+	}
+	return exitCodes, nil
+}
+
+// Run a linter and return its findings output, recording a linter-error
+// wart if it didn't run successfully
+func (tf *TargetFile) runLinter(name string, cmd *exec.Cmd, stream outputStream) string {
+	start := time.Now()
+	ctx := tf.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	output, otherOutput, err := lintOutput(ctx, cmd, stream, tf.config.LinterTimeout)
+	if tf.config.Verbose {
+		outcome := "ok"
+		if err != nil {
+			outcome = err.Error()
+		}
+		log.Printf("%s: `%s` took %s (%s)", tf.config.displayPath(tf.Path), strings.Join(cmd.Args, " "), time.Now().Sub(start), outcome)
+	}
+	status := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		status = exitErr.ExitCode()
+	} else if err == errLinterTimeout {
+		tf.addLinterError(name, fmt.Sprintf("timed out after %s, raise -linter-timeout if it needs longer", tf.config.LinterTimeout))
+		return output
+	} else if errors.Is(err, exec.ErrNotFound) {
+		// Uninstalled since we looked for it
+		binaries.Lock()
+		binaries.found[cmd.Args[0]] = false
+		binaries.Unlock()
+		tf.notInstalled(name)
+		return output
+	} else if err != nil {
+		tf.addLinterError(name, err.Error())
+		return output
+	}
+	if codes, ok := tf.config.ExitCodes[name]; ok && !codes[status] {
+		detail := firstLine(otherOutput)
+		if len(detail) == 0 {
+			detail = firstLine(output)
+		}
+		tf.addLinterError(name, fmt.Sprintf("exited with status %d: %s", status, detail))
+	}
+	return output
+}
+
+func (tf *TargetFile) addLinterError(name string, message string) {
+	tf.AddWart(Wart{
+		Reporter:  "lintblame",
+		Line:      1,
+		IssueCode: "linter-error",
+		Message:   fmt.Sprintf("%s %s", name, message),
+		Severity:  SeverityError,
+	})
+}
+
+// Build a wart from a linter's output, recording a linter error instead
+// when its line or column doesn't parse
+func (tf *TargetFile) parseWart(reporter string, line string, column string, issueCode string, message string) (Wart, bool) {
+	wart, err := NewWart(reporter, line, column, issueCode, message)
+	if err != nil {
+		tf.addLinterError(reporter, err.Error())
+		return wart, false
+	}
+	return wart, true
+}
+
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			return line
+		}
+	}
+	return ""
+}
+
+// Run `pep8`, which reports on stdout
+func (tf *TargetFile) Pep8() {
+	cmd := tf.command("pep8", append(tf.config.lineLengthArgs("--max-line-length"), tf.LintPath)...)
+	results := tf.runLinter("pep8", cmd, stdoutStream)
+	parsed := rexes["pep8"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		wart, ok := tf.parseWart("PEP8", group[1], group[2], group[3], group[4])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run a go command against the file, with any extra flags before the file
+// argument. E.g., `go build`. The go tool reports on stderr, mixed in with
+// `# package` headers, so both streams are parsed.
+func (tf *TargetFile) GoCmd(goCmd string, flags ...string) {
+	dir, file := filepath.Split(tf.LintPath)
+	args := append([]string{goCmd}, flags...)
+	cmd := tf.command("go", append(args, file)...)
+	cmd.Dir = dir
+	results := tf.runLinter("go"+goCmd, cmd, combinedStreams)
+	parsed := rexes["goBuild"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		column := group[2]
+		if len(column) == 0 {
+			column = "0"
+		}
+		wart, ok := tf.parseWart(goCmd, group[1], column, "-", group[3])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run `go build`, throwing away the binary it builds for main packages
+func (tf *TargetFile) GoBuild() {
+	tf.GoCmd("build", "-o", os.DevNull)
+}
+
+// Run `go vet`
+func (tf *TargetFile) GoVet() {
+	tf.GoCmd("vet", tf.config.GoVetFlags...)
+}
+
+// Run `golint`, which always exits 0 and reports on stdout
+func (tf *TargetFile) GoLint() {
+	cmd := tf.command("golint", tf.LintPath)
+	results := tf.runLinter("golint", cmd, stdoutStream)
+	parsed := rexes["golint"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		wart, ok := tf.parseWart("golint", group[1], group[2], "-", group[3])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run `staticcheck` over the file's package, keeping what it found in
+// this file
+func (tf *TargetFile) StaticCheck() {
+	dir := filepath.Dir(tf.LintPath)
+	cmd := tf.command("staticcheck", ".")
+	cmd.Dir = dir
+	results := tf.runLinter("staticcheck", cmd, combinedStreams)
+	parsed := rexes["staticcheck"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		if !tf.isLintPath(dir, group[1]) {
+			continue
+		}
+		wart, ok := tf.parseWart("staticcheck", group[2], group[3], group[5], group[4])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run `errcheck` over the file's package, keeping the unchecked errors in
+// this file
+func (tf *TargetFile) ErrCheck() {
+	dir := filepath.Dir(tf.LintPath)
+	cmd := tf.command("errcheck", ".")
+	cmd.Dir = dir
+	results := tf.runLinter("errcheck", cmd, combinedStreams)
+	parsed := rexes["errcheck"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		if !tf.isLintPath(dir, group[1]) {
+			continue
+		}
+		wart, ok := tf.parseWart("errcheck", group[2], group[3], "-", "unchecked error: "+group[4])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run `ineffassign` over the file's package, keeping the assignments in
+// this file
+func (tf *TargetFile) IneffAssign() {
+	dir := filepath.Dir(tf.LintPath)
+	cmd := tf.command("ineffassign", ".")
+	cmd.Dir = dir
+	results := tf.runLinter("ineffassign", cmd, combinedStreams)
+	parsed := rexes["ineffassign"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		if !tf.isLintPath(dir, group[1]) {
+			continue
+		}
+		if wart, ok := tf.parseWart("ineffassign", group[2], group[3], "-", group[4]); ok {
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// Run the file's package's tests, putting each failure's messages on the
+// lines in this file that logged them
+func (tf *TargetFile) GoTest() {
+	dir := filepath.Dir(tf.LintPath)
+	cmd := tf.command("go", "test", ".")
+	cmd.Dir = dir
+	results := tf.runLinter("gotest", cmd, combinedStreams)
+	test := "-"
+	for _, line := range strings.Split(results, "\n") {
+		if group := rexes["goTestFail"].FindStringSubmatch(line); group != nil {
+			test = group[1]
+		} else if group := rexes["goTestLog"].FindStringSubmatch(line); group != nil && tf.isLintPath(dir, group[1]) {
+			if wart, ok := tf.parseWart("go test", group[2], "0", test, group[3]); ok {
+				tf.AddWart(wart)
+			}
+		}
+	}
+}
+
+// Run `gocyclo` on the file, flagging functions more complex than -cyclo-max
+func (tf *TargetFile) GoCyclo() {
+	cmd := tf.command("gocyclo", "-over", strconv.Itoa(tf.config.CycloMax), tf.LintPath)
+	results := tf.runLinter("gocyclo", cmd, stdoutStream)
+	parsed := rexes["gocyclo"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		message := fmt.Sprintf("%s has cyclomatic complexity %s (over %d)", group[3], group[1], tf.config.CycloMax)
+		if wart, ok := tf.parseWart("gocyclo", group[4], group[5], "-", message); ok {
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// The parts of `gosec -fmt=json` output we use
+type gosecReport struct {
+	Issues []struct {
+		Severity string `json:"severity"`
+		RuleID   string `json:"rule_id"`
+		Details  string `json:"details"`
+		File     string `json:"file"`
+		Line     string `json:"line"` // e.g. "12", or "12-14" for a span
+		Column   string `json:"column"`
+	}
+}
+
+// Run `gosec` over the file's package, keeping the issues in this file
+func (tf *TargetFile) GoSec() {
+	dir := filepath.Dir(tf.LintPath)
+	cmd := tf.command("gosec", "-fmt=json", "-quiet", ".")
+	cmd.Dir = dir
+	results := tf.runLinter("gosec", cmd, stdoutStream)
+	var report gosecReport
+	if err := json.Unmarshal([]byte(results), &report); err != nil {
+		if len(strings.TrimSpace(results)) > 0 {
+			tf.addLinterError("gosec", "unreadable output: "+err.Error())
+		}
+		return
+	}
+	for _, issue := range report.Issues {
+		if !tf.isLintPath(dir, issue.File) {
+			continue
+		}
+		line := strings.SplitN(issue.Line, "-", 2)[0]
+		column := issue.Column
+		if _, err := strconv.Atoi(column); err != nil {
+			column = "0"
+		}
+		message := fmt.Sprintf("%s (severity %s)", issue.Details, issue.Severity)
+		wart, ok := tf.parseWart("gosec", line, column, issue.RuleID, message)
+		if !ok {
+			continue
+		}
+		if severity, ok := ratedSeverities[issue.Severity]; ok {
+			wart.Severity = severity
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// The parts of `golangci-lint run --out-format json` output we use
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Severity   string `json:"Severity"` // Empty unless configured
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	}
+}
+
+// Run `golangci-lint` over the file's package, keeping the issues in this
+// file. Each is reported by the linter inside golangci-lint that found it.
+func (tf *TargetFile) GolangciLint() {
+	dir := filepath.Dir(tf.LintPath)
+	cmd := tf.command("golangci-lint", "run", "--out-format", "json", ".")
+	cmd.Dir = dir
+	results := tf.runLinter("golangci-lint", cmd, stdoutStream)
+	var report golangciReport
+	if err := json.Unmarshal([]byte(results), &report); err != nil {
+		if len(strings.TrimSpace(results)) > 0 {
+			tf.addLinterError("golangci-lint", "unreadable output: "+err.Error())
+		}
+		return
+	}
+	for _, issue := range report.Issues {
+		if !tf.isLintPath(dir, issue.Pos.Filename) {
+			continue
+		}
+		code, message := "-", issue.Text
+		// typecheck's messages are compiler errors, e.g. `undefined: x`
+		if group := rexes["golangciRule"].FindStringSubmatch(issue.Text); group != nil && issue.FromLinter != "typecheck" {
+			code, message = group[1], group[2]
+		}
+		wart, ok := tf.parseWart(issue.FromLinter, strconv.Itoa(issue.Pos.Line), strconv.Itoa(issue.Pos.Column), code, message)
+		if !ok {
+			continue
+		}
+		if severity, ok := parseSeverity(issue.Severity); ok {
+			wart.Severity = severity
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Whether a path from a package-wide linter run in dir is this file
+func (tf *TargetFile) isLintPath(dir string, path string) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path == tf.LintPath
+}
+
+// Analyzers built into go vet. A -vettool can bring its own.
+var vetAnalyzers = map[string]bool{
+	"appends": true, "asmdecl": true, "assign": true, "atomic": true,
+	"bools": true, "buildtag": true, "cgocall": true, "composites": true,
+	"copylocks": true, "defers": true, "directive": true, "errorsas": true,
+	"framepointer": true, "httpresponse": true, "ifaceassert": true,
+	"loopclosure": true, "lostcancel": true, "nilfunc": true, "printf": true,
+	"shift": true, "sigchanyzer": true, "slog": true, "stdmethods": true,
+	"stdversion": true, "stringintconv": true, "structtag": true,
+	"testinggoroutine": true, "tests": true, "timeformat": true,
+	"unmarshal": true, "unreachable": true, "unsafeptr": true,
+	"unusedresult": true,
+}
+
+// go vet flags that would change what we run against or how it reports
+var vetReservedFlags = map[string]bool{"C": true, "json": true, "n": true, "x": true}
+
+// Turn analyzer settings like `printf=false`, `printf.funcs=Logf` or
+// `vettool=/path/to/shadow` into go vet flags, rejecting anything that would clash with the file
+// argument lintblame supplies
+func vetFlags(analyzers []string) ([]string, error) {
+	flags := make([]string, 0, len(analyzers))
+	hasVettool := false
+	for _, analyzer := range analyzers {
+		if strings.HasPrefix(strings.TrimLeft(analyzer, "-"), "vettool=") {
+			hasVettool = true
+		}
+	}
+	for _, analyzer := range analyzers {
+		analyzer = strings.TrimLeft(strings.TrimSpace(analyzer), "-")
+		if len(analyzer) == 0 {
+			continue
+		}
+		name := strings.SplitN(analyzer, "=", 2)[0]
+		// An analyzer's own flags are name.flag, e.g. printf.funcs
+		analyzerName := strings.SplitN(name, ".", 2)[0]
+		switch {
+		case strings.Contains(name, "/") || len(analyzerName) == 0 || filepath.Ext(name) == ".go":
+			return nil, fmt.Errorf("%s looks like a file or package, not an analyzer", analyzer)
+		case vetReservedFlags[name]:
+			return nil, fmt.Errorf("-%s conflicts with how lintblame runs go vet", name)
+		case !vetAnalyzers[analyzerName] && name != "vettool" && !hasVettool:
+			return nil, fmt.Errorf("unknown go vet analyzer %s", name)
+		}
+		flags = append(flags, "-"+analyzer)
+	}
+	return flags, nil
+}
+
+// Run `pylint`. Findings go to stdout; stderr only has config and crash
+// noise.
+func (tf *TargetFile) PyLint() {
+	// The template gets us the message ids, e.g. C0111, that -ignore takes
+	args := []string{"--output-format=text", "--msg-template={line},{column}:{msg_id}:{msg}"}
+	args = append(args, tf.config.lineLengthArgs("--max-line-length")...)
+	cmd := tf.command("pylint", append(args, tf.LintPath)...)
+	results := tf.runLinter("pylint", cmd, stdoutStream)
+	parsed := rexes["pylint"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		wart, ok := tf.parseWart("Pylint", group[1], group[2], group[3], group[4])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run `flake8`, which reports on stdout
+func (tf *TargetFile) Flake8() {
+	cmd := tf.command("flake8", append(tf.config.lineLengthArgs("--max-line-length"), tf.LintPath)...)
+	results := tf.runLinter("flake8", cmd, stdoutStream)
+	parsed := rexes["flake8"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		wart, ok := tf.parseWart("flake8", group[1], group[2], group[3], group[4])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// Run `pyflakes`, which only leaves out the column for some messages
+func (tf *TargetFile) PyFlakes() {
+	cmd := tf.command("pyflakes", tf.LintPath)
+	results := tf.runLinter("pyflakes", cmd, stdoutStream)
+	parsed := rexes["pyflakes"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		column := group[2]
+		if len(column) == 0 {
+			column = "0"
+		}
+		if wart, ok := tf.parseWart("pyflakes", group[1], column, "-", group[3]); ok {
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// Run `mypy`, taking the error code from the `[code]` suffix when there
+// is one
+func (tf *TargetFile) MyPy() {
+	cmd := tf.command("mypy", "--show-column-numbers", "--no-error-summary", tf.LintPath)
+	results := tf.runLinter("mypy", cmd, stdoutStream)
+	parsed := rexes["mypy"].FindAllStringSubmatch(results, -1)
+	for _, group := range parsed {
+		code := group[4]
+		if len(code) == 0 {
+			code = "-"
+		}
+		wart, ok := tf.parseWart("mypy", group[1], group[2], code, group[3])
+		if !ok {
+			continue
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// The parts of `bandit -f json` output we use
+type banditReport struct {
+	Results []struct {
+		TestID    string `json:"test_id"`
+		Severity  string `json:"issue_severity"`
+		Text      string `json:"issue_text"`
+		Line      int    `json:"line_number"`
+		ColOffset *int   `json:"col_offset"` // 0-based, and missing from older versions
+	}
+}
+
+// Run `bandit`, Python's counterpart to gosec
+func (tf *TargetFile) Bandit() {
+	cmd := tf.command("bandit", "-f", "json", "-q", tf.LintPath)
+	results := tf.runLinter("bandit", cmd, stdoutStream)
+	var report banditReport
+	if err := json.Unmarshal([]byte(results), &report); err != nil {
+		if len(strings.TrimSpace(results)) > 0 {
+			tf.addLinterError("bandit", "unreadable output: "+err.Error())
+		}
+		return
+	}
+	for _, result := range report.Results {
+		column := 0
+		if result.ColOffset != nil {
+			column = *result.ColOffset + 1
+		}
+		message := fmt.Sprintf("%s (severity %s)", result.Text, result.Severity)
+		wart, ok := tf.parseWart("bandit", strconv.Itoa(result.Line), strconv.Itoa(column), result.TestID, message)
+		if !ok {
+			continue
+		}
+		if severity, ok := ratedSeverities[result.Severity]; ok {
+			wart.Severity = severity
+		}
+		tf.AddWart(wart)
+	}
+}
+
+// The parts of `eslint --format json` output we use
+type eslintReport []struct {
+	Messages []struct {
+		RuleID   string `json:"ruleId"` // null for parse errors
+		Severity int    `json:"severity"`
+		Message  string `json:"message"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+	}
+}
+
+// Run `eslint` on JavaScript and TypeScript files
+func (tf *TargetFile) ESLint() {
+	cmd := tf.command("eslint", "--format", "json", tf.LintPath)
+	results := tf.runLinter("eslint", cmd, stdoutStream)
+	var report eslintReport
+	if err := json.Unmarshal([]byte(results), &report); err != nil {
+		if len(strings.TrimSpace(results)) > 0 {
+			tf.addLinterError("eslint", "unreadable output: "+err.Error())
+		}
+		return
+	}
+	for _, file := range report {
+		for _, message := range file.Messages {
+			code := message.RuleID
+			if len(code) == 0 {
+				code = "-"
+			}
+			wart, ok := tf.parseWart("eslint", strconv.Itoa(message.Line), strconv.Itoa(message.Column), code, message.Message)
+			if !ok {
+				continue
+			}
+			// 2 is "error", 1 is "warn"
+			if message.Severity == 2 || len(message.RuleID) == 0 {
+				wart.Severity = SeverityError
+			}
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// The parts of `rubocop --format json` output we use
+type rubocopReport struct {
+	Files []struct {
+		Offenses []struct {
+			Severity string `json:"severity"`
+			Message  string `json:"message"`
+			CopName  string `json:"cop_name"`
+			Location struct {
+				Line   int `json:"line"`
+				Column int `json:"column"`
+			} `json:"location"`
+		} `json:"offenses"`
+	} `json:"files"`
+}
+
+// Rubocop's severities as ours
+var rubocopSeverities = map[string]Severity{
+	"fatal":      SeverityError,
+	"error":      SeverityError,
+	"warning":    SeverityWarning,
+	"convention": SeverityInfo,
+	"refactor":   SeverityInfo,
+	"info":       SeverityInfo,
+}
+
+// Run `rubocop` on Ruby files
+func (tf *TargetFile) Rubocop() {
+	cmd := tf.command("rubocop", "--format", "json", tf.LintPath)
+	results := tf.runLinter("rubocop", cmd, stdoutStream)
+	var report rubocopReport
+	if err := json.Unmarshal([]byte(results), &report); err != nil {
+		if len(strings.TrimSpace(results)) > 0 {
+			tf.addLinterError("rubocop", "unreadable output: "+err.Error())
+		}
+		return
+	}
+	for _, file := range report.Files {
+		for _, offense := range file.Offenses {
+			location := offense.Location
+			wart, ok := tf.parseWart("rubocop", strconv.Itoa(location.Line), strconv.Itoa(location.Column), offense.CopName, offense.Message)
+			if !ok {
+				continue
+			}
+			if severity, ok := rubocopSeverities[offense.Severity]; ok {
+				wart.Severity = severity
+			}
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// Line numbers that have warts, in ascending order
+func (tf *TargetFile) SortedLines() []int {
+	return sortedLines(tf.Warts)
+}
+
+func sortedLines(warts map[int][]Wart) []int {
+	lines := make([]int, 0, len(warts))
+	for line := range warts {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Split sorted line numbers into runs of adjacent lines with the same
+// blame name
+func groupConsecutive(tf *TargetFile, lines []int) [][]int {
+	groups := make([][]int, 0)
+	for i, line := range lines {
+		last := len(groups) - 1
+		if i > 0 && line == lines[i-1]+1 && tf.BlameName(line) == tf.BlameName(lines[i-1]) {
+			groups[last] = append(groups[last], line)
+		} else {
+			groups = append(groups, []int{line})
+		}
+	}
+	return groups
+}
+
+// Blame names for uncommitted lines with -label-unstaged
+const (
+	stagedName   = "you (staged)"
+	unstagedName = "you (unstaged)"
+)
+
+// Get the blame name for a given line. Lines nobody has committed yet are
+// "uncommitted", rather than git's "Not Committed Yet", or with
+// -label-unstaged, whichever of stagedName and unstagedName fits.
+func (tf *TargetFile) BlameName(line int) string {
+	blame, ok := tf.BlameFor(line)
+	if !ok {
+		return "-"
+	} else if blame.Uncommitted() {
+		if !tf.config.LabelUnstaged {
+			return "uncommitted"
+		} else if tf.Unstaged[line] {
+			return unstagedName
+		}
+		return stagedName
+	}
+	return blame.Name
+}
+
+// Describe who last changed a line and when, e.g. `alice, a1b2c3d,
+// 2023-04-01`
+func (tf *TargetFile) BlameLabel(line int) string {
+	blame, ok := tf.BlameFor(line)
+	if !ok || blame.Uncommitted() {
+		return tf.BlameName(line)
+	}
+	commit := blame.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return fmt.Sprintf("%s, %s, %s", blame.Name, commit, blame.Date.Format("2006-01-02"))
+}
+
+// Lint a single file with the options, as Lint would. Fails if the file
+// doesn't exist, e.g. when it was deleted or is midway through an editor's
+// rename-and-replace save.
+func NewTargetFile(path string, opts Options) (*TargetFile, error) {
+	c, err := opts.config([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	return newTargetFile(context.Background(), c, c.ArgPaths[0])
+}
+
+// Create a TargetFile linted with c, killing its linters if ctx is
+// cancelled
+func newTargetFile(ctx context.Context, c *Config, path string) (*TargetFile, error) {
+	tf := TargetFile{
+		Path:     path,
+		LintPath: path,
+		Warts:    make(map[int][]Wart),
+		config:   c,
+		ctx:      ctx,
+	}
+	var bytes []byte
+	var err error
+	if len(c.AtRev) > 0 {
+		if tf.atRev, err = c.atRevFor(path); err != nil {
+			return nil, err
+		}
+		bytes, err = showAtRev(path, tf.atRev)
+		if err != nil {
+			// The file didn't exist at that revision
+			return nil, err
+		}
+		var tmpDir string
+		tmpDir, err = tf.lintCopy(bytes)
+		if err == nil {
+			defer os.RemoveAll(tmpDir)
+		}
+	} else {
+		bytes, err = ioutil.ReadFile(path)
+	}
+	if os.IsNotExist(err) {
+		return nil, err
+	} else if err != nil {
+		// Report it like any other wart rather than taking down the watch
+		// loop
+		tf.ContentLines = []string{""}
+		tf.AddWart(Wart{
+			Reporter:  "lintblame",
+			Line:      1,
+			IssueCode: "read-error",
+			Message:   err.Error(),
+			Severity:  SeverityError,
+		})
+		return &tf, nil
+	}
+	tf.lint(bytes)
+	if c.Fix && len(tf.Warts) > 0 && tf.Fix() {
+		// Show what the fixers left
+		if bytes, err = ioutil.ReadFile(path); err != nil {
+			return nil, err
+		}
+		tf.Warts = make(map[int][]Wart)
+		tf.Linters = nil
+		tf.lint(bytes)
+	}
+	return &tf, nil
+}
+
+// The content's lines, without the `\r` of CRLF line endings, which would
+// otherwise be printed along with the line
+func splitLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// Blame the file's content and run the linters against it
+func (tf *TargetFile) lint(content []byte) {
+	tf.ContentLines = splitLines(string(content))
+	if !tf.config.NoBlame {
+		tf.Blame()
+	}
+	if tf.config.DiffOnly {
+		tf.Diff()
+	}
+	tf.runLinters(linters)
+}
+
+// Run the linters that can run against the file, at most
+// tf.config.LinterJobs at a time
+func (tf *TargetFile) runLinters(linters []Linter) {
+	sem := make(chan bool, tf.config.LinterJobs)
+	var wg sync.WaitGroup
+	for _, linter := range linters {
+		if !tf.canRun(linter) {
+			continue
+		}
+		wg.Add(1)
+		sem <- true
+		go func(linter Linter) {
+			defer wg.Done()
+			start := time.Now()
+			linter.Run(tf)
+			tf.timed(linter.Name(), time.Now().Sub(start))
+			<-sem
+		}(linter)
+	}
+	wg.Wait()
+}
+
+// Lint paths with c until there are none left. Once ctx is done, the rest
+// are skipped.
+func lintWorker(ctx context.Context, c *Config, paths chan string, files chan *TargetFile) {
+	for path := range paths {
+		if ctx.Err() != nil {
+			files <- nil
+			continue
+		}
+		files <- lintFile(ctx, c, path)
+	}
+}
+
+// Create a TargetFile, reusing the last results while watching if nothing
+// they depend on has changed since. Returns nil if the file is skipped.
+func lintFile(ctx context.Context, c *Config, filepath string) *TargetFile {
+	if c.Once {
+		// Nothing will ask for the results again
+		tf, err := newTargetFile(ctx, c, filepath)
+		if err != nil {
+			return nil
+		}
+		return tf
+	}
+	content, readErr := ioutil.ReadFile(filepath)
+	sum := lintKey(filepath, content)
+	linted.Lock()
+	cached, ok := linted.files[filepath]
+	linted.Unlock()
+	if readErr == nil && ok && cached.sum == sum {
+		return cached.tf
+	}
+	tf, err := newTargetFile(ctx, c, filepath)
+	if err != nil {
+		// Skip it for now. If it comes back, watching will pick it up.
+		return nil
+	}
+	if readErr == nil && ctx.Err() == nil {
+		linted.Lock()
+		linted.files[filepath] = lintedFile{sum: sum, tf: tf}
+		linted.Unlock()
+	}
+	return tf
+}
+
+// Hash what a file's results depend on: its content, the siblings with its
+// extension, since package-wide linters like go vet read them too, and the
+// repo's HEAD and index, which blame, -diff and -label-unstaged read
+func lintKey(path string, content []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(content)
+	stamp := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(h, "\x00%s %d %d", path, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	dir, ext := filepath.Dir(path), filepath.Ext(path)
+	if siblings, err := ioutil.ReadDir(dir); err == nil {
+		for _, info := range siblings {
+			if sibling := filepath.Join(dir, info.Name()); !info.IsDir() && sibling != path && filepath.Ext(sibling) == ext {
+				stamp(sibling)
+			}
+		}
+	}
+	if gitDir := gitDirFor(path); len(gitDir) > 0 {
+		for _, name := range []string{"HEAD", "index", filepath.Join("logs", "HEAD")} {
+			stamp(filepath.Join(gitDir, name))
+		}
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// The .git directory of the file's repo, or "" outside of one. Worktrees
+// and submodules have a .git file pointing at it instead.
+func gitDirFor(path string) string {
+	root := gitRootFor(path)
+	if len(root) == 0 {
+		return ""
+	}
+	gitDir := filepath.Join(root, ".git")
+	if info, err := os.Stat(gitDir); err != nil || info.IsDir() {
+		return gitDir
+	}
+	content, err := ioutil.ReadFile(gitDir)
+	if err != nil {
+		return gitDir
+	}
+	pointer := strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+	if !filepath.IsAbs(pointer) {
+		pointer = filepath.Join(root, pointer)
+	}
+	return pointer
+}
+
+// A file's last results, and the hash of what they depend on
+type lintedFile struct {
+	sum [sha256.Size]byte
+	tf  *TargetFile
+}
+
+// The last results for each file, so watching only re-lints the files
+// that changed
+var linted = struct {
+	sync.Mutex
+	files map[string]lintedFile
+}{files: make(map[string]lintedFile)}
+
+// Drop a file's results, e.g. once it's deleted
+func forgetLinted(path string) {
+	linted.Lock()
+	delete(linted.files, path)
+	linted.Unlock()
+}
+
+// Drop every file's results, so the next run lints them all again
+func forgetAllLinted() {
+	linted.Lock()
+	linted.files = make(map[string]lintedFile)
+	linted.Unlock()
+}
+
+// Receive n results from lintFiles, dropping files that were skipped
+func receiveFiles(c chan *TargetFile, n int) []*TargetFile {
+	files := make([]*TargetFile, 0, n)
+	for i := 0; i < n; i++ {
+		if tf := <-c; tf != nil {
+			files = append(files, tf)
+		}
+	}
+	return files
+}
+
+// Lint the paths with c, using a pool of c.Jobs workers, delivering each
+// TargetFile as it completes. Skipped files are delivered as nil. The
+// channels are buffered so nothing leaks if the caller gives up once ctx is
+// done.
+func lintFiles(ctx context.Context, c *Config, filepaths []string) chan *TargetFile {
+	files := make(chan *TargetFile, len(filepaths))
+	paths := make(chan string, len(filepaths))
+	for _, path := range filepaths {
+		paths <- path
+	}
+	close(paths)
+	for i := 0; i < c.Jobs && i < len(filepaths); i++ {
+		go lintWorker(ctx, c, paths, files)
+	}
+	return files
+}
+
+// Returns paths to watch for a given directory, and with -r its
+// subdirectories too
+func (c *Config) getDirFiles(dirPath string) ([]string, error) {
+	if c.Recursive {
+		return c.walkDirFiles(dirPath), nil
+	}
+	files, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory %s: %s", dirPath, err)
+	}
+	filepaths := make([]string, len(files))
+	for i, fileInfo := range files {
+		filepaths[i] = path.Join(dirPath, fileInfo.Name())
+	}
+	return c.filterFiles(filepaths), nil
+}
+
+// Directories -r doesn't descend into
+var skippedDirs = map[string]bool{".git": true, "vendor": true, "node_modules": true}
+
+func (c *Config) walkDirFiles(dirPath string) []string {
+	filepaths := make([]string, 0)
+	filepath.Walk(dirPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Skip whatever we can't read, e.g. a directory deleted mid-walk
+			return nil
+		}
+		if info.IsDir() {
+			if file != dirPath && skippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		filepaths = append(filepaths, file)
+		return nil
+	})
+	return c.filterFiles(filepaths)
+}
+
+// Returns paths to watch for the current branch
+func (c *Config) gitBranchFiles() ([]string, error) {
+	dirtyFiles, err := c.gitOutput("diff", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dirty files: %s", err)
+	}
+
+	branchFiles, err := c.branchDiffFiles()
+	if err != nil {
+		// E.g. a fresh repo, or a CI checkout without the base branch
+		branchFallback.Do(func() {
+			log.Print(err, "; watching every tracked file instead")
+		})
+		branchFiles, err = c.gitOutput("ls-files")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tracked files: %s", err)
+		}
+	}
+
+	allFiles := append(
+		strings.Split(string(dirtyFiles), "\n"),
+		strings.Split(string(branchFiles), "\n")...,
+	)
+	return c.filterFiles(allFiles), nil
+}
+
+// Warns once that the branch's files couldn't be diffed, rather than on
+// every refresh
+var branchFallback sync.Once
+
+// Files changed between the base branch and HEAD
+func (c *Config) branchDiffFiles() ([]byte, error) {
+	if len(c.BaseBranch) == 0 {
+		return nil, errors.New("no base branch to diff against, pass one with -base")
+	}
+	out, err := c.gitOutput("diff", "--name-only", c.BaseBranch+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..HEAD: %s", c.BaseBranch, err)
+	}
+	return out, nil
+}
+
+// Run git in the working dir
+func (c *Config) gitOutput(args ...string) ([]byte, error) {
+	return gitRetry(func() *exec.Cmd {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = c.WorkingDir
+		return cmd
+	})
+}
+
+// How long to wait before each retry of a git command that lost the race
+// for the index lock
+var gitRetryDelays = []time.Duration{100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond}
+
+// Run the git command newCmd builds, building and running it again if it
+// fails because another git process, e.g. a commit in progress, holds
+// .git/index.lock
+func gitRetry(newCmd func() *exec.Cmd) ([]byte, error) {
+	out, err := newCmd().Output()
+	for _, delay := range gitRetryDelays {
+		if !isLockError(err) {
+			break
+		}
+		time.Sleep(delay)
+		out, err = newCmd().Output()
+	}
+	return out, err
+}
+
+// Whether git failed because another git process holds the index lock
+func isLockError(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+	stderr := string(exitErr.Stderr)
+	return strings.Contains(stderr, "index.lock") || strings.Contains(stderr, "Another git process")
+}
+
+// The branch -b diffs against when -base isn't given: whatever origin's
+// HEAD points at, else main, else master, else none
+func (c *Config) defaultBaseBranch() string {
+	cmd := exec.Command("git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	cmd.Dir = c.WorkingDir
+	if out, err := cmd.Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	for _, branch := range []string{"main", "master"} {
+		cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", branch+"^{commit}")
+		cmd.Dir = c.WorkingDir
+		if cmd.Run() == nil {
+			return branch
+		}
+	}
+	return ""
+}
+
+// Filters candidate paths to those that should be watched
+func (c *Config) filterFiles(filepaths []string) []string {
+	goodstuffs := make([]string, 0)
+	for _, filepath := range filepaths {
+		if len(filepath) > 0 {
+			if lintableExt(path.Ext(filepath)) {
+				if !strings.HasPrefix(filepath, "/") {
+					filepath = path.Join(c.WorkingDir, filepath)
+				}
+				if c.included(filepath) {
+					goodstuffs = append(goodstuffs, filepath)
+				}
+			}
+		}
+	}
+	return dropIgnored(goodstuffs)
+}
+
+// Drop the paths git ignores, asking each repo about its own files in one
+// batch
+func dropIgnored(paths []string) []string {
+	byRoot := make(map[string][]string)
+	for _, path := range paths {
+		if root := gitRootFor(path); len(root) > 0 {
+			byRoot[root] = append(byRoot[root], path)
+		}
+	}
+	ignored := make(map[string]bool)
+	for root, rootPaths := range byRoot {
+		cmd := exec.Command("git", "-C", root, "check-ignore", "--stdin")
+		cmd.Stdin = strings.NewReader(strings.Join(rootPaths, "\n") + "\n")
+		// Exits 1 when nothing is ignored
+		out, _ := cmd.Output()
+		for _, path := range strings.Split(string(out), "\n") {
+			ignored[path] = true
+		}
+	}
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !ignored[path] {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
+
+// Whether the path passes -include and -exclude
+func (c *Config) included(path string) bool {
+	if len(c.Include) > 0 && !c.matchesAny(c.Include, path) {
+		return false
+	}
+	return !c.matchesAny(c.Exclude, path)
+}
+
+// Whether any of the globs match the path's base name or a trailing part
+// of it under the working dir, so `migrations/*.py` matches at any depth
+func (c *Config) matchesAny(globs []string, path string) bool {
+	rel, err := filepath.Rel(c.WorkingDir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, glob := range globs {
+		for i := range parts {
+			if match, _ := filepath.Match(glob, strings.Join(parts[i:], "/")); match {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Split a comma-separated list of globs, failing on a malformed one
+func parseGlobs(list string) ([]string, error) {
+	globs := make([]string, 0)
+	for _, glob := range strings.Split(list, ",") {
+		glob = strings.TrimSpace(glob)
+		if len(glob) == 0 {
+			continue
+		}
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("pattern %s: %s", glob, err)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// The path shown to the user for a file: relative to c.WorkingDir, or
+// to its git root with -paths-from-git-root, unless that would climb out of
+// it. File operations keep using the absolute path.
+func (c *Config) displayPath(path string) string {
+	if c.AbsPaths {
+		return path
+	}
+	base := c.WorkingDir
+	if c.GitRootPaths {
+		base = gitRootFor(path)
+		if len(base) == 0 {
+			var err error
+			base, err = os.Getwd()
+			if err != nil {
+				return path
+			}
+		}
+	}
+	if len(base) == 0 {
+		return path
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// Cache of what each -since-commit revision is in each repo, by root and
+// revision, and of whether a repo's commits are ancestors of it. Each repo
+// resolves it on its own, so e.g. `HEAD~3` means something in every one of
+// them.
+var ancestors = struct {
+	sync.Mutex
+	revs  map[string]string // "" when the repo doesn't have the revision
+	known map[string]bool   // By resolved revision and commit
+}{revs: make(map[string]string), known: make(map[string]bool)}
+
+// Whether the commit is already contained in c.SinceCommit, in the repo at
+// root
+func (c *Config) isAncestor(root string, commit string) bool {
+	if strings.HasPrefix(commit, uncommittedHash) {
+		return false
+	}
+	ancestors.Lock()
+	defer ancestors.Unlock()
+	revKey := root + "\x00" + c.SinceCommit
+	rev, ok := ancestors.revs[revKey]
+	if !ok {
+		out, err := exec.Command("git", "-C", root, "rev-parse", "--verify", c.SinceCommit+"^{commit}").Output()
+		if err != nil {
+			log.Printf("%s isn't a revision in %s; showing warts on every line there", c.SinceCommit, root)
+		}
+		rev = strings.TrimSpace(string(out))
+		ancestors.revs[revKey] = rev
+	}
+	if len(rev) == 0 {
+		return false
+	}
+	key := rev + "\x00" + commit
+	if isAncestor, ok := ancestors.known[key]; ok {
+		return isAncestor
+	}
+	cmd := exec.Command("git", "-C", root, "merge-base", "--is-ancestor", commit, rev)
+	ancestors.known[key] = cmd.Run() == nil
+	return ancestors.known[key]
+}
+
+// Whether the line was changed after -since-commit. Lines git couldn't
+// blame are kept rather than hidden.
+func changedSinceCommit(tf *TargetFile, line int) bool {
+	blame, ok := tf.BlameFor(line)
+	root := gitRootFor(tf.Path)
+	if !ok || len(root) == 0 {
+		return true
+	}
+	return !tf.config.isAncestor(root, blame.Commit)
+}
+
+// An inclusive range of line numbers, for -lines
+type lineRange struct {
+	start int
+	end   int
+}
+
+// Parse -lines, e.g. `100-200,250`
+func parseLineRanges(spec string) ([]lineRange, error) {
+	ranges := make([]lineRange, 0)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("bad line range %q", part)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.Atoi(bounds[1])
+			if err != nil || end < start {
+				return nil, fmt.Errorf("bad line range %q", part)
+			}
+		}
+		ranges = append(ranges, lineRange{start, end})
+	}
+	return ranges, nil
+}
+
+// Whether -lines leaves the line in, which it does when it isn't given
+func (c *Config) inLineRanges(line int) bool {
+	if len(c.Lines) == 0 {
+		return true
+	}
+	for _, r := range c.Lines {
+		if line >= r.start && line <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// The file's warts that pass the configured filters
+func filterWarts(tf *TargetFile) map[int][]Wart {
+	if len(tf.config.SinceCommit) == 0 && !tf.config.DiffOnly && tf.config.SeverityMin == SeverityInfo && len(tf.config.Lines) == 0 {
+		return tf.Warts
+	}
+	filtered := make(map[int][]Wart)
+	for line, warts := range tf.Warts {
+		if !tf.config.inLineRanges(line) {
+			continue
+		}
+		if len(tf.config.SinceCommit) > 0 && !changedSinceCommit(tf, line) {
+			continue
+		}
+		if tf.config.DiffOnly && tf.ChangedLines != nil && !tf.ChangedLines[line] {
+			continue
+		}
+		for _, wart := range warts {
+			if wart.Severity >= tf.config.SeverityMin {
+				filtered[line] = append(filtered[line], wart)
+			}
+		}
+	}
+	return filtered
+}
+
+// The ` (name)` after a line number, or nothing with -no-blame
+func blameColumn(label string, nameColor string) string {
+	if config.NoBlame {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", color(nameColor, label))
+}
+
+// Print the warts on the given lines under a header per reporter
+func printReporterGroups(w io.Writer, targetFile *TargetFile, lineWarts map[int][]Wart, lines []int) {
+	byReporter := make(map[string][]int)
+	for _, line := range lines {
+		for _, wart := range lineWarts[line] {
+			reporterLines := byReporter[wart.Reporter]
+			if len(reporterLines) == 0 || reporterLines[len(reporterLines)-1] != line {
+				byReporter[wart.Reporter] = append(reporterLines, line)
+			}
+		}
+	}
+	reporters := make([]string, 0, len(byReporter))
+	for reporter := range byReporter {
+		reporters = append(reporters, reporter)
+	}
+	sort.Strings(reporters)
+	for _, reporter := range reporters {
+		fmt.Fprintln(w, color("bold", reporter))
+		for _, line := range byReporter[reporter] {
+			blameName := targetFile.BlameName(line)
+			nameColor := "blue"
+			if config.isMe(blameName) {
+				nameColor = "yellow"
+			}
+			for _, wart := range lineWarts[line] {
+				if wart.Reporter != reporter {
+					continue
+				}
+				fmt.Fprintf(
+					w,
+					"    %s:%s %s %s\n",
+					color("bold", fmt.Sprintf("%d", line)),
+					blameColumn(blameName, nameColor),
+					color(wart.Severity.Color(), fmt.Sprintf("[%s]", wart.IssueCode)),
+					wart.Detail(),
+				)
+			}
+		}
+	}
+}
+
+// Print the target file's issues
+func printWarts(w io.Writer, targetFile *TargetFile) {
+	lineWarts := filterWarts(targetFile)
+	if len(lineWarts) == 0 {
+		fmt.Fprintf(
+			w,
+			"%s [%s]",
+			color("green", config.displayPath(targetFile.Path)),
+			color("bold", "clean"),
+		)
+		if config.ShowLinters {
+			fmt.Fprint(w, " ", color("dim", targetFile.LinterSummary()))
+		}
+		if fixed := fixSummary(targetFile); len(fixed) > 0 {
+			fmt.Fprint(w, " ", fixed)
+		}
+	} else {
+		count := 0
+		for _, warts := range lineWarts {
+			count += len(warts)
+		}
+		issues := "issues"
+		if count == 1 {
+			issues = "issue"
+		}
+		fmt.Fprintln(w, color("yellow", config.displayPath(targetFile.Path)), color("dim", fmt.Sprintf("(%d %s)", count, issues)))
+		if fixed := fixSummary(targetFile); len(fixed) > 0 {
+			fmt.Fprintln(w, "   ", fixed)
+		}
+	}
+	lines := sortedLines(lineWarts)
+	hidden := 0
+	if config.PrintLimit > 0 && len(lines) > config.PrintLimit {
+		hidden = len(lines) - config.PrintLimit
+		lines = lines[:config.PrintLimit]
+	}
+	var groups [][]int
+	switch {
+	case config.GroupBy == "reporter":
+		printReporterGroups(w, targetFile, lineWarts, lines)
+	case config.GroupConsecutive:
+		groups = groupConsecutive(targetFile, lines)
+	default:
+		groups = make([][]int, len(lines))
+		for i, line := range lines {
+			groups[i] = []int{line}
+		}
+	}
+	printed := 0 // The last line printed, as a wart line or context
+	for i, group := range groups {
+		line := group[0]
+		blameName := targetFile.BlameName(line)
+		nameColor := "blue"
+		if config.isMe(blameName) {
+			nameColor = "yellow"
+		}
+		if len(group) > 1 {
+			fmt.Fprintf(
+				w,
+				"%s:%s\n",
+				color("bold", fmt.Sprintf("%d-%d", line, group[len(group)-1])),
+				blameColumn(blameName, nameColor),
+			)
+			for _, line := range group {
+				for _, wart := range lineWarts[line] {
+					fmt.Fprintf(
+						w,
+						"    %d %s %s\n",
+						line,
+						color(wart.Severity.Color(), fmt.Sprintf("[%s %s]", wart.Reporter, wart.IssueCode)),
+						color("bold", wart.Detail()),
+					)
+				}
+			}
+			continue
+		}
+		if config.Context > 0 {
+			// Don't repeat what the last wart line's context showed
+			first := line - config.Context
+			if first <= printed {
+				first = printed + 1
+			}
+			printContext(w, targetFile, first, line-1)
+		}
+		fmt.Fprintf(
+			w,
+			"%s:%s %s\n",
+			color("bold", fmt.Sprintf("%d", line)),
+			blameColumn(targetFile.BlameLabel(line), nameColor),
+			sourceLine(targetFile, line),
+		)
+		printed = line
+		if config.Context > 0 {
+			// Stop short of the next wart line, which is printed as one
+			last := line + config.Context
+			if i+1 < len(groups) && last >= groups[i+1][0] {
+				last = groups[i+1][0] - 1
+			}
+			printContext(w, targetFile, line+1, last)
+			printed = last
+		}
+		for _, wart := range lineWarts[line] {
+			fmt.Fprintf(
+				w,
+				"    %s %s\n",
+				color(wart.Severity.Color(), fmt.Sprintf("[%s %s]", wart.Reporter, wart.IssueCode)),
+				color("bold", wart.Detail()),
+			)
+		}
+	}
+	if hidden > 0 {
+		fmt.Fprintln(w, color("dim", fmt.Sprintf("...and %d more lines with warts", hidden)))
+	}
+	if config.ShowLinters && len(lineWarts) > 0 {
+		fmt.Fprintln(w, color("dim", targetFile.LinterSummary()))
+	}
+}
+
+// The wart line's source, trimmed, or bold and with its indentation to
+// line up with -context
+func sourceLine(tf *TargetFile, line int) string {
+	text := tf.ContentLine(line)
+	if config.Context == 0 {
+		return strings.TrimSpace(text)
+	}
+	return color("bold", strings.TrimRight(text, " \t\r"))
+}
+
+// Print the file's lines from first to last, dimmed and marked `N-` like
+// grep's context lines, skipping any past either end of the file
+func printContext(w io.Writer, tf *TargetFile, first int, last int) {
+	lastLine := len(tf.ContentLines)
+	if lastLine > 0 && len(tf.ContentLines[lastLine-1]) == 0 {
+		// What follows the final newline
+		lastLine--
+	}
+	for line := first; line <= last; line++ {
+		if line < 1 || line > lastLine {
+			continue
+		}
+		text := strings.TrimRight(tf.ContentLines[line-1], " \t\r")
+		fmt.Fprintln(w, color("dim", fmt.Sprintf("%d- %s", line, text)))
+	}
+}
+
+// A file's rendered output, written to stdout in one go so files never
+// interleave
+type renderedFile struct {
+	path  string
+	mine  int // How many of the file's warts are on the user's lines
+	out   *bytes.Buffer
+	warts []jsonWart  // For -format json, which prints them all at the end
+	file  *TargetFile // Likewise for -format sarif and checkstyle
+}
+
+// Count the file's warts that are on lines blamed on the user
+func mineCount(tf *TargetFile) int {
+	count := 0
+	for line, warts := range filterWarts(tf) {
+		if config.isMe(tf.BlameName(line)) {
+			count += len(warts)
+		}
+	}
+	return count
+}
+
+func renderFile(tf *TargetFile) renderedFile {
+	block := renderedFile{path: tf.Path, mine: mineCount(tf), out: new(bytes.Buffer)}
+	switch config.Format {
+	case "kv":
+		printWartsKV(block.out, tf)
+		return block
+	case "json":
+		block.warts = jsonWarts(tf)
+		return block
+	case "sarif", "checkstyle":
+		block.file = tf
+		return block
+	}
+	if config.Quiet && len(filterWarts(tf)) == 0 {
+		return block
+	}
+	printWarts(block.out, tf)
+	fmt.Fprintln(block.out, "")
+	return block
+}
+
+// Sort buffered output for -order and -order-dir, given the filepaths from
+// oldest to most recently modified
+func sortBlocks(blocks []renderedFile, filepaths []string) {
+	switch config.Order {
+	case "path":
+		sort.Slice(blocks, func(i, j int) bool { return blocks[i].path < blocks[j].path })
+	case "mine":
+		sort.Slice(blocks, func(i, j int) bool {
+			if blocks[i].mine != blocks[j].mine {
+				return blocks[i].mine > blocks[j].mine
+			}
+			return blocks[i].path < blocks[j].path
+		})
+	case "modified":
+		age := make(map[string]int)
+		for i, path := range filepaths {
+			age[path] = i
+		}
+		sort.Slice(blocks, func(i, j int) bool {
+			if config.OrderDir == "newest-first" {
+				return age[blocks[i].path] > age[blocks[j].path]
+			}
+			return age[blocks[i].path] < age[blocks[j].path]
+		})
+	}
+}
+
+// Clear the screen, if -clear, and print the header
+func clear() {
+	if config.Clear {
+		clearScreen()
+	}
+	fmt.Println(header())
+}
+
+// Clear the terminal, if stdout is one. Windows' console has no `clear`, so
+// it gets `cls`; everything else understands the escapes `clear` prints.
+func clearScreen() {
+	if !isTerminal(os.Stdout) {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		cmd := exec.Command("cmd", "/c", "cls")
+		cmd.Stdout = os.Stdout
+		cmd.Run()
+		return
+	}
+	fmt.Print("\033[H\033[2J\033[3J")
+}
+
+func header() string {
+	return fmt.Sprintf(
+		"%s %s%s %s",
+		color("bold", "---"),
+		color("bold", "lint"),
+		color("red", "blame"),
+		color("bold", "---"),
+	)
+}
+
+// Lint the files and print the results, returning the run's totals
+func printResults(modTimes ModifiedTimes) Summary {
+	filepaths := modTimes.SortaSorted()
+	start := time.Now()
+	ctx := context.Background()
+	if config.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Deadline)
+		defer cancel()
+	}
+	c := lintFiles(ctx, &config, filepaths)
+	text := config.Format == "text"
+	quietClean := text && config.QuietClean
+	// Stream files as they arrive unless we need them all first, to sort
+	// them, to find out whether the run was clean, or to print one JSON
+	// document
+	streaming := config.Order == "arrival" && !quietClean && !documentFormat() && !config.Stats
+	cleared := false
+	blocks := make([]renderedFile, 0, len(filepaths))
+	summary := Summary{Timestamp: start, Total: len(filepaths)}
+	written := 0
+	flush := func(block renderedFile) {
+		if block.out.Len() == 0 {
+			return
+		}
+		if text && config.RepeatHeader > 0 && written > 0 && written%config.RepeatHeader == 0 {
+			fmt.Println(header())
+		}
+		os.Stdout.Write(block.out.Bytes())
+		written++
+	}
+	for i := 0; i < len(filepaths) && !summary.Truncated; i++ {
+		if text && !cleared && streaming {
+			clear()
+			cleared = true
+		}
+		var tf *TargetFile
+		select {
+		case tf = <-c:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// Out of time. Anything arriving now may have had its linters
+			// killed partway through.
+			summary.Truncated = true
+			continue
+		}
+		if tf == nil {
+			continue
+		}
+		summary.Add(tf)
+		if config.Stats {
+			continue
+		}
+		block := renderFile(tf)
+		if streaming {
+			flush(block)
+		} else {
+			blocks = append(blocks, block)
+		}
+	}
+	if !summary.Truncated {
+		summary.compareLastRun()
+	}
+	if config.Stats {
+		printStats(os.Stdout, summary)
+		if summary.Truncated {
+			log.Printf("Run truncated by -deadline %s: %d of %d files finished", config.Deadline, summary.Files, summary.Total)
+		}
+		return summary
+	}
+	if quietClean && !summary.Truncated && summary.Warts() == 0 {
+		// Leave the screen alone, even though we'd normally clear it
+		bell := ""
+		if config.Bell {
+			bell = "\a"
+		}
+		fmt.Printf(
+			"%s%s all clean at %d:%d:%d\n",
+			bell,
+			color("green", "✓"),
+			start.Hour(),
+			start.Minute(),
+			start.Second(),
+		)
+		return summary
+	}
+	if !streaming {
+		sortBlocks(blocks, filepaths)
+		if text {
+			clear()
+		}
+		for _, block := range blocks {
+			flush(block)
+		}
+	}
+	summary.Duration = time.Now().Sub(start)
+	switch config.Format {
+	case "json":
+		printWartsJSON(blocks, summary)
+	case "sarif":
+		printWartsSARIF(blocks, summary)
+	case "checkstyle":
+		printWartsCheckstyle(blocks)
+	}
+	printFooter(summary)
+	return summary
+}
+
+// Exit statuses
+const (
+	exitWarts = 1
+	// Something went wrong besides the linting itself, e.g. a bad flag or a
+	// linter falling over
+	exitInternal  = 2
+	exitTruncated = 3 // -deadline cut the run short
+	// Ctrl-C, as shells report it
+	exitInterrupted = 130
+)
+
+// The status a run exits with, worst first
+func exitStatus(summary Summary) int {
+	if summary.Truncated {
+		return exitTruncated
+	} else if summary.Internal > 0 {
+		return exitInternal
+	} else if summary.Warts() > 0 {
+		return exitWarts
+	}
+	return 0
+}
+
+// Like log.Fatal, but with a status that can't be mistaken for warts
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	os.Exit(exitInternal)
+}
+
+func fatalf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(exitInternal)
+}
+
+// Log a diagnostic, only with -v
+func debugf(format string, v ...interface{}) {
+	if config.Verbose {
+		log.Printf(format, v...)
+	}
+}
+
+func getFileInfo(filepath string) (os.FileInfo, error) {
+	fileInfo, err := os.Stat(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get info for path %s: %s", filepath, err)
+	}
+	return fileInfo, nil
+}
+
+// Returns path slice based on the command line argument paths
+func (c *Config) argPathPaths() ([]string, error) {
+	paths := make([]string, 0)
+	files := make([]string, 0)
+	for _, argPath := range c.ArgPaths {
+		fileInfo, err := getFileInfo(argPath)
+		if err != nil {
+			return nil, err
+		}
+		if fileInfo.IsDir() {
+			dirFiles, err := c.getDirFiles(argPath)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, dirFiles...)
+		} else {
+			files = append(files, argPath)
+		}
+	}
+	paths = append(paths, c.filterFiles(files)...)
+
+	// A file can be named both directly and through its directory
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	return unique, nil
+}
+
+// The deepest directory that holds all of dirs
+func commonDir(dirs []string) string {
+	common := dirs[0]
+	for _, dir := range dirs[1:] {
+		for common != dir && !strings.HasPrefix(dir, common+string(filepath.Separator)) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// Return the paths to be watched
+func (c *Config) targetPaths() ([]string, error) {
+	if c.BranchMode {
+		return c.gitBranchFiles()
+	} else if c.Stdin {
+		return c.filterFiles(c.StdinPaths), nil
+	}
+	return c.argPathPaths()
+}
+
+// Read newline-separated paths, e.g. from `git diff --name-only`, resolving
+// them like path arguments. Paths that don't exist, such as deleted files
+// in a diff, are left out.
+func readPaths(r io.Reader) []string {
+	paths := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if path, _, err := resolveArgPath(line); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fatal("Failed to read paths: ", err)
+	}
+	return paths
+}
+
+// Whether the file is a terminal rather than a pipe or a regular file
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Resolve a path argument to an absolute, symlink-free path, and the
+// directory to work from for it. Everything downstream uses these rather
+// than the process's cwd. Symlinks are resolved since git refuses to blame
+// paths that go through them.
+func resolveArgPath(target string) (string, string, error) {
+	absPath, err := filepath.Abs(target)
+	if err != nil {
+		return "", "", err
+	}
+	absPath, err = filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", "", err
+	}
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return "", "", err
+	}
+	if stat.IsDir() {
+		return absPath, absPath, nil
+	}
+	return absPath, filepath.Dir(absPath), nil
+}
+
+// Resolve a revision to its full commit hash, exiting if git doesn't know it
+func (c *Config) resolveRev(rev string) string {
+	cmd := exec.Command("git", "rev-parse", "--verify", rev+"^{commit}")
+	cmd.Dir = c.WorkingDir
+	out, err := cmd.Output()
+	if err != nil {
+		fatal("Unknown revision: ", rev)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// init() runs when testing as well, so keep this named something else.
+func initConfig() {
+	var branch, stdin bool
+	flag.BoolVar(&branch, "b", false, "Run against current branch")
+	flag.BoolVar(&stdin, "stdin", false, "Lint the newline-separated paths read from stdin, e.g. from git diff --name-only")
+	flag.BoolVar(&config.Recursive, "r", false, "Include files in subdirectories, except .git, vendor, and node_modules")
+	flag.StringVar(&config.BaseBranch, "base", "", "Branch -b diffs against (default: origin's HEAD, then main, then master)")
+	flag.BoolVar(&config.ShowLinters, "show-linters", false, "Show which linters ran for each file")
+	flag.BoolVar(&config.GitRootPaths, "paths-from-git-root", false, "Display paths relative to the git root")
+	flag.BoolVar(&config.AbsPaths, "abs", false, "Display absolute paths")
+	flag.BoolVar(&config.TUI, "tui", false, "Browse results in an interactive terminal UI")
+	flag.BoolVar(&config.Once, "once", false, "Lint once and exit: 0 if clean, 1 for warts, 2 if something went wrong")
+	flag.BoolVar(&config.DiffOnly, "diff", false, "Only show warts on lines git diff shows as changed: since the base branch with -b, else uncommitted")
+	flag.StringVar(&config.SinceCommit, "since-commit", "", "Only show warts on lines changed after this revision")
+	flag.StringVar(&config.Order, "order", "arrival", "Order of files in the output: arrival, path, modified, or mine (most warts on your lines first)")
+	flag.StringVar(&config.Me, "me", "", "Blame name to treat as yours (default: git's user.name)")
+	flag.StringVar(&config.OrderDir, "order-dir", "newest-last", "With -order modified, newest-first or newest-last (closest to the prompt)")
+	flag.IntVar(&config.Jobs, "jobs", runtime.NumCPU(), "Number of files to lint at once")
+	flag.IntVar(&config.LinterJobs, "linter-jobs", 1, "Number of linters to run at once per file")
+	flag.DurationVar(&config.LinterTimeout, "linter-timeout", 30*time.Second, "Kill a linter that runs longer than this on one file (0 for no limit)")
+	flag.StringVar(&config.GroupBy, "group-by", "line", "Group text output by line or by reporter")
+	flag.IntVar(&config.Context, "context", 0, "Show N lines of source before and after each wart line")
+	flag.BoolVar(&config.GroupConsecutive, "group-consecutive", false, "Group adjacent wart lines with the same blame name")
+	flag.BoolVar(&config.Fix, "fix", false, "Rewrite files with warts using the safe autofixers for them, e.g. gofmt and black, then lint again")
+	flag.BoolVar(&config.FixDirty, "fix-dirty", false, "With -fix, also fix files that have uncommitted changes")
+	flag.IntVar(&config.RepeatHeader, "repeat-header", 0, "Reprint the header every N files (0 to never)")
+	flag.StringVar(&config.AtRev, "at", "", "Lint files as they were at this revision")
+	flag.BoolVar(&config.NoBlame, "no-blame", false, "Skip git blame, and leave out who last changed each line")
+	flag.BoolVar(&config.LabelUnstaged, "label-unstaged", false, "Blame uncommitted lines on you, marked (staged) or (unstaged)")
+	flag.StringVar(&config.Format, "format", "text", "Output format: text, kv, json, sarif, or checkstyle")
+	flag.IntVar(&config.CycloMax, "cyclo-max", 15, "With gocyclo, flag functions with a cyclomatic complexity over this")
+	flag.IntVar(&config.MaxLineLength, "max-line-length", 0, "Line length limit for pep8, pylint, flake8, black, isort and their fixers (0 for their own defaults)")
+	flag.IntVar(&config.PrintLimit, "limit", 0, "Print at most this many lines with warts per file (0 for no limit)")
+	flag.BoolVar(&config.Clear, "clear", false, "Clear the screen before each run's text output, so only the latest results show")
+	flag.BoolVar(&config.NoFooter, "no-footer", false, "Don't print the [last ran at ...] footer in text output")
+	flag.BoolVar(&config.NoColor, "no-color", false, "Don't color output. Also off when NO_COLOR is set or stdout isn't a terminal.")
+	flag.BoolVar(&config.Verbose, "v", false, "Log what lintblame is doing to stderr, e.g. each linter it runs")
+	flag.BoolVar(&config.Stats, "stats", false, "Only print counts of files, clean and dirty files, and warts, on one line")
+	flag.BoolVar(&config.Profile, "profile", false, "Print how long each linter took, across every file")
+	flag.BoolVar(&config.Quiet, "quiet", false, "Only print files with warts, and the summary")
+	flag.BoolVar(&config.QuietClean, "quiet-clean", false, "When a run is clean, just print one line instead of repainting")
+	flag.BoolVar(&config.Bell, "bell", false, "With -quiet-clean, ring the terminal bell on clean runs")
+	flag.DurationVar(&config.Interval, "interval", 5*time.Second, "How often to look for added and removed files while watching, e.g. 500ms or 30s")
+	flag.DurationVar(&config.Deadline, "deadline", 0, "Give up on a run after this long and print what finished, exiting 3 with -once (0 for no deadline)")
+	var include, exclude string
+	flag.StringVar(&include, "include", "", "Comma-separated globs to lint only matching files, e.g. '*.go,cmd/*'")
+	flag.StringVar(&exclude, "exclude", "", "Comma-separated globs of files to skip, e.g. '*_test.go,migrations/*.py'")
+	var govetAnalyzers string
+	flag.StringVar(&govetAnalyzers, "govet-analyzers", "", "Comma-separated go vet analyzer flags, e.g. printf=false,vettool=/path/to/shadow")
+	var exitCodes string
+	flag.StringVar(&exitCodes, "linter-exit-codes", "", "Exit statuses meaning a linter ran, e.g. pylint=0:4:16,pep8=0:1")
+	var linterList string
+	flag.StringVar(&linterList, "linters", strings.Join(defaultLinters(), ","), "Comma-separated linters to run, out of "+strings.Join(linterNames, ","))
+	var severityMin string
+	var ignore string
+	flag.StringVar(&ignore, "ignore", "", "Drop warts with these reporter:code pairs, e.g. pep8:E501,pylint:C")
+	var lines string
+	flag.StringVar(&lines, "lines", "", "Only show warts in these line ranges of a single file, e.g. 100-200,250")
+	flag.StringVar(&severityMin, "severity-min", "info", "Only show warts at least this severe: info, warning, or error")
+	var pyLinter string
+	flag.StringVar(&pyLinter, "py-linter", defaultPyLinter, "Python linters to run: pep8+pylint, flake8, or pyflakes")
+	linterToggles := make(map[string]*bool)
+	for _, name := range linterNames {
+		linterToggles[name] = flag.Bool(name, linterDefault(name), fmt.Sprintf("Run %s, overriding -linters", name))
+	}
+	flag.Parse()
+
+	if branch && stdin {
+		fatal("-stdin can't be used with -b")
+	}
+	config.BranchMode = branch
+	config.Stdin = stdin
+	if branch {
+		// Only -b needs a repo. Everything else lints without blame outside
+		// of one.
+		gitRoot, err := env.GitRoot()
+		if err != nil {
+			fatal("-b needs to be run from inside a git repo")
+		}
+		config.WorkingDir = gitRoot
+	} else if stdin {
+		if len(flag.Args()) > 0 {
+			fatal("-stdin can't be used with path arguments")
+		}
+		_, workingDir, err := resolveArgPath(".")
+		if err != nil {
+			fatal("Unable to find the working directory: ", err)
+		}
+		config.WorkingDir = workingDir
+		config.StdinPaths = readPaths(os.Stdin)
+	} else {
+		targets := flag.Args()
+		if len(targets) == 0 {
+			targets = []string{"."}
+		}
+		workingDirs := make([]string, len(targets))
+		for i, target := range targets {
+			argPath, workingDir, err := resolveArgPath(target)
+			if err != nil {
+				fatal("Unable to process argument: ", err)
+			}
+			config.ArgPaths = append(config.ArgPaths, argPath)
+			workingDirs[i] = workingDir
+		}
+		config.WorkingDir = commonDir(workingDirs)
+	}
+
+	// Flags that were passed win over the settings file, which in turn
+	// counts as passing the rest
+	passed := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { passed[f.Name] = true })
+	loadConfigFile(config.WorkingDir, passed)
+	flag.Visit(func(f *flag.Flag) { passed[f.Name] = true })
+
+	codes, err := parseExitCodes(exitCodes)
+	if err != nil {
+		fatal("Bad -linter-exit-codes: ", err)
+	}
+	config.ExitCodes = codes
+
+	config.Linters = make(map[string]bool)
+	for _, name := range strings.Split(linterList, ",") {
+		name = strings.TrimSpace(name)
+		if lookupLinter(name) == nil {
+			fatal("Unknown linter in -linters: ", name)
+		}
+		config.Linters[name] = true
+	}
+	// Custom linters run unless a -linters list leaves them out
+	if !passed["linters"] {
+		for _, name := range customLinters {
+			config.Linters[name] = true
+		}
+	}
+	pyLinters, ok := pyLinterSets[pyLinter]
+	if !ok {
+		fatal("Unknown -py-linter: ", pyLinter)
+	}
+	// -py-linter picks the Python linters in the default list. It only
+	// overrides an explicit -linters list when it's passed as well.
+	if !passed["linters"] || passed["py-linter"] {
+		for _, linters := range pyLinterSets {
+			for _, name := range linters {
+				config.Linters[name] = false
+			}
+		}
+		for _, name := range pyLinters {
+			config.Linters[name] = true
+		}
+	}
+	// Only the toggles that were actually passed override the list
+	for name, enabled := range linterToggles {
+		if passed[name] {
+			config.Linters[name] = *enabled
+		}
+	}
+
+	config.SeverityMin, ok = parseSeverity(severityMin)
+	if !ok {
+		fatal("Unknown -severity-min: ", severityMin)
+	}
+	if config.Ignore, err = parseIgnore(ignore); err != nil {
+		fatal("Bad -ignore: ", err)
+	}
+	for _, reporter := range config.unknownReporters() {
+		log.Printf("No linter reports warts as %s, so -ignore won't drop anything for it", reporter)
+	}
+	if config.AbsPaths && config.GitRootPaths {
+		fatal("-abs can't be used with -paths-from-git-root")
+	}
+	if config.NoBlame {
+		switch {
+		case len(config.SinceCommit) > 0:
+			fatal("-since-commit can't be used with -no-blame")
+		case config.DiffOnly:
+			fatal("-diff can't be used with -no-blame")
+		case config.LabelUnstaged:
+			fatal("-label-unstaged can't be used with -no-blame")
+		case config.Order == "mine":
+			fatal("-order mine can't be used with -no-blame")
+		}
+	}
+	if len(lines) > 0 {
+		if config.Lines, err = parseLineRanges(lines); err != nil {
+			fatal("Bad -lines: ", err)
+		}
+		// Line numbers only mean something for one file
+		if len(config.ArgPaths) != 1 {
+			fatal("-lines needs a single file to lint")
+		}
+		if stat, err := os.Stat(config.ArgPaths[0]); err != nil || stat.IsDir() {
+			fatal("-lines needs a single file to lint")
+		}
+	}
+
+	if len(os.Getenv("NO_COLOR")) > 0 || !isTerminal(os.Stdout) {
+		config.NoColor = true
+	}
+	if config.Once && config.TUI {
+		fatal("-once can't be used with -tui")
+	}
+	if config.Interval <= 0 {
+		fatal("-interval must be positive")
+	}
+	if config.Jobs < 1 || config.LinterJobs < 1 {
+		fatal("-jobs and -linter-jobs must be at least 1")
+	}
+	switch config.Order {
+	case "arrival", "path", "modified", "mine":
+	default:
+		fatal("Unknown -order: ", config.Order)
+	}
+	if config.OrderDir != "newest-first" && config.OrderDir != "newest-last" {
+		fatal("Unknown -order-dir: ", config.OrderDir)
+	}
+	if config.GroupBy != "line" && config.GroupBy != "reporter" {
+		fatal("Unknown -group-by: ", config.GroupBy)
+	}
+	if config.GroupBy == "reporter" && config.GroupConsecutive {
+		fatal("-group-consecutive can't be used with -group-by reporter")
+	}
+	if config.MaxLineLength < 0 {
+		fatal("-max-line-length can't be negative")
+	}
+	if config.Context < 0 {
+		fatal("-context can't be negative")
+	} else if config.Context > 0 && (config.GroupConsecutive || config.GroupBy == "reporter") {
+		fatal("-context only works with warts grouped by line")
+	}
+	switch config.Format {
+	case "text", "kv":
+	case "json", "sarif", "checkstyle":
+		// Nothing but the document on stdout
+		config.NoColor = true
+	default:
+		fatal("Unknown -format: ", config.Format)
+	}
+	if config.Stats && config.Format != "text" && config.Format != "kv" && config.Format != "json" {
+		fatal("-stats only works with -format text, kv or json")
+	}
+	if config.Stats && config.TUI {
+		fatal("-stats can't be used with -tui")
+	}
+	if config.Include, err = parseGlobs(include); err != nil {
+		fatal("Bad -include: ", err)
+	}
+	if config.Exclude, err = parseGlobs(exclude); err != nil {
+		fatal("Bad -exclude: ", err)
+	}
+	if len(govetAnalyzers) > 0 {
+		flags, err := vetFlags(strings.Split(govetAnalyzers, ","))
+		if err != nil {
+			fatal("Bad -govet-analyzers: ", err)
+		}
+		config.GoVetFlags = flags
+	}
+
+	if branch && len(config.BaseBranch) == 0 {
+		config.BaseBranch = config.defaultBaseBranch()
+	}
+	// Each repo resolves -since-commit itself, but a typo should still fail
+	// fast when there's only the one
+	if len(config.SinceCommit) > 0 {
+		if _, err := config.gitOutput("rev-parse", "--show-toplevel"); err == nil {
+			config.resolveRev(config.SinceCommit)
+		}
+	}
+	if config.DiffOnly && len(config.AtRev) > 0 {
+		fatal("-diff can't be used with -at")
+	}
+	if len(config.AtRev) > 0 {
+		if config.Fix {
+			fatal("-fix can't be used with -at")
+		}
+		if config.LabelUnstaged {
+			fatal("-label-unstaged can't be used with -at")
+		}
+		// Each repo resolves it itself, as with -since-commit
+		if _, err := config.gitOutput("rev-parse", "--show-toplevel"); err == nil {
+			config.resolveRev(config.AtRev)
+		}
+	}
+	if config.InitialPaths, err = config.targetPaths(); err != nil {
+		fatal(err)
+	}
+	enabled := make([]string, 0, len(config.Linters))
+	for _, linter := range linters {
+		if config.Linters[linter.Name()] {
+			enabled = append(enabled, linter.Name())
+		}
+	}
+	debugf("Linting %d files under %s with %s", len(config.InitialPaths), config.WorkingDir, strings.Join(enabled, ", "))
+}
+
+// Watch the target files until q is typed, re-linting one and calling run
+// whenever it changes. The file list itself is refreshed every -interval.
+func watch(modTimes *ModifiedTimes, keys <-chan string, run func(ModifiedTimes)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fatal("Failed to watch files: ", err)
+	}
+	defer watcher.Close()
+	watchDirs(watcher, modTimes)
+	refresh := time.NewTicker(config.Interval)
+	defer refresh.Stop()
+	for {
+		select {
+		case event := <-watcher.Events:
+			if _, ok := modTimes.TimeMap[event.Name]; !ok {
+				continue
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				// Gone for now. Either it comes back with a create, or the
+				// next refresh drops it from the list.
+				forgetLinted(event.Name)
+			} else if modTimes.CheckTime(event.Name) {
+				debugf("%s changed", config.displayPath(event.Name))
+				run(*modTimes)
+			}
+		case key := <-keys:
+			switch key {
+			case "q":
+				return
+			case "r":
+				forgetAllLinted()
+				run(*modTimes)
+			case "c":
+				if config.Format == "text" {
+					clearScreen()
+					fmt.Println(header())
+				}
+			}
+		case err := <-watcher.Errors:
+			log.Print("Watch error: ", err)
+		case <-refresh.C:
+			refreshed, err := NewModifiedTimes(&config)
+			if err != nil {
+				// E.g. a directory argument was deleted. Keep watching what
+				// we had.
+				log.Print("Failed to refresh the watched files: ", err)
+				continue
+			}
+			oldLen := modTimes.Len()
+			modTimes = refreshed
+			watchDirs(watcher, modTimes)
+			if modTimes.Len() != oldLen {
+				debugf("Now watching %d files, up from %d", modTimes.Len(), oldLen)
+				run(*modTimes)
+			}
+		}
+	}
+}
+
+// Commands typed while watching: q to quit, r to re-lint everything and c
+// to clear the screen, each followed by enter. There are none when stdin
+// isn't a terminal, e.g. with -stdin.
+func watchKeys() <-chan string {
+	if config.Stdin || !isTerminal(os.Stdin) {
+		return nil
+	}
+	keys := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			keys <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+	return keys
+}
+
+// Watch the directories holding the target files. Watching the files
+// themselves would lose them to editors that save by replacing the file.
+func watchDirs(watcher *fsnotify.Watcher, modTimes *ModifiedTimes) {
+	for file := range modTimes.TimeMap {
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			log.Print("Failed to watch ", filepath.Dir(file), ": ", err)
+		}
+	}
+}
+
+// Exit on Ctrl-C or SIGTERM with the terminal's colors reset, since the
+// output may have been cut off partway through a colored line
+func handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if !config.NoColor {
+			fmt.Println(colors["end"])
+		}
+		os.Exit(exitInterrupted)
+	}()
+}
+
+// Run the lintblame command: parse the flags, then lint once or watch
+func Main() {
+	initConfig()
+	modTimes, err := NewModifiedTimes(&config)
+	if err != nil {
+		fatal(err)
+	}
+	if config.TUI {
+		runTUI(modTimes)
+		return
+	}
+	// The TUI gets Ctrl-C as a key press instead
+	handleSignals()
+	summary := printResults(*modTimes)
+	if config.Once {
+		os.Exit(exitStatus(summary))
+	}
+	watch(modTimes, watchKeys(), func(m ModifiedTimes) { printResults(m) })
+}
@@ -0,0 +1,2043 @@
+package lintblame
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "encoding/xml"
+    "testing"
+    "time"
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "log"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+
+    "github.com/gdamore/tcell/v2"
+)
+
+var blah = fmt.Sprintf("stop complaining")
+
+func TestSortaSorted(t *testing.T) {
+    mt := ModifiedTimes{}
+    mt.TimeMap = make(map[string]time.Time, 3)
+    mt.TimeMap["abc"] = time.Now().AddDate(0, 0, -1)
+    mt.TimeMap["def"] = time.Now()
+    mt.TimeMap["ghi"] = time.Now().AddDate(0, 0, -2)
+    result := mt.SortaSorted()
+    if result[0] != "ghi" || result[1] != "abc" || result[2] != "def" {
+        t.Error("Bad order")
+    }
+}
+
+func TestSortaSortedAnyMapOrder(t *testing.T) {
+    now := time.Now()
+    mt := ModifiedTimes{TimeMap: make(map[string]time.Time)}
+    // Out of order by name, and with a tie, so no iteration order can get
+    // it right by luck
+    for name, daysAgo := range map[string]int{"a": 2, "b": 5, "c": 0, "d": 3, "e": 1, "f": 4, "g": 3} {
+        mt.TimeMap[name] = now.AddDate(0, 0, -daysAgo)
+    }
+    expected := "[b f d g a e c]"
+    for i := 0; i < 20; i++ {
+        if result := fmt.Sprint(mt.SortaSorted()); result != expected {
+            t.Fatalf("Expected %s, got %s", expected, result)
+        }
+    }
+    if result := (ModifiedTimes{TimeMap: map[string]time.Time{}}).SortaSorted(); len(result) != 0 {
+        t.Errorf("Expected nothing for no files, got %v", result)
+    }
+}
+
+func TestLinterSummary(t *testing.T) {
+    tf := TargetFile{config: &config}
+    tf.Linters = []LinterStatus{
+        {Name: "go build", Ran: true},
+        {Name: "go vet", Ran: true},
+        {Name: "pylint", Reason: "not installed"},
+    }
+    expected := "[ran: go build, go vet; skipped: pylint (not installed)]"
+    if result := tf.LinterSummary(); result != expected {
+        t.Errorf("Expected %q, got %q", expected, result)
+    }
+}
+
+func TestLinterRegistry(t *testing.T) {
+    seen := make(map[string]bool)
+    for _, linter := range linters {
+        if seen[linter.Name()] {
+            t.Errorf("%s is registered twice", linter.Name())
+        }
+        seen[linter.Name()] = true
+        if _, ok := defaultExitCodes[linter.Name()]; !ok {
+            t.Errorf("%s has no default exit codes", linter.Name())
+        }
+    }
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"gofmt": true, "pep8": true}
+    tf := &TargetFile{config: &config, Path: "x.go", Warts: make(map[int][]Wart)}
+    for _, name := range []string{"gofmt", "pep8", "govet"} {
+        tf.canRun(lookupLinter(name))
+    }
+    expected := "[ran: gofmt; skipped: pep8 (not for .go), govet (disabled)]"
+    if _, err := exec.LookPath("gofmt"); err == nil && tf.LinterSummary() != expected {
+        t.Errorf("Expected %q, got %q", expected, tf.LinterSummary())
+    }
+
+    // go test only runs for test files, which are where its failures go
+    config.Linters = map[string]bool{"gotest": true}
+    tf = &TargetFile{config: &config, Path: "x.go", Warts: make(map[int][]Wart)}
+    if tf.canRun(lookupLinter("gotest")) || tf.LinterSummary() != "[skipped: gotest (not for x.go)]" {
+        t.Errorf("Expected gotest to skip x.go, got %q", tf.LinterSummary())
+    }
+}
+
+func TestVetFlags(t *testing.T) {
+    flags, err := vetFlags([]string{"printf=false", "-shadow", "vettool=/usr/bin/shadow"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(flags) != 3 || flags[0] != "-printf=false" || flags[1] != "-shadow" {
+        t.Errorf("Unexpected flags %v", flags)
+    }
+    flags, err = vetFlags([]string{"printf.funcs=Logf", "-printf.funcs=Warnf"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Join(flags, " ") != "-printf.funcs=Logf -printf.funcs=Warnf" {
+        t.Errorf("Unexpected flags %v", flags)
+    }
+    for _, bad := range []string{"./...", "main.go", "json", "C=/tmp", "nosuchanalyzer", "nosuchanalyzer.funcs=Logf"} {
+        if _, err := vetFlags([]string{bad}); err == nil {
+            t.Errorf("Expected %s to be rejected", bad)
+        }
+    }
+}
+
+func TestUnreadableFiles(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    unreadable := filepath.Join(dir, "unreadable.py")
+    if err := ioutil.WriteFile(unreadable, []byte("x = 1\n"), 0000); err != nil {
+        t.Fatal(err)
+    }
+    deleted := filepath.Join(dir, "deleted.py")
+    paths := []string{deleted}
+    if os.Geteuid() != 0 {
+        // root can read it anyway
+        paths = append(paths, unreadable)
+    }
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Jobs = 1
+    config.LinterJobs = 1
+    c := lintFiles(context.Background(), &config, paths)
+    for range paths {
+        tf := <-c
+        if tf == nil {
+            // The deleted file is skipped
+            continue
+        }
+        if tf.Path == deleted {
+            t.Errorf("Expected %s to be skipped, got %v", deleted, tf.Warts)
+        }
+        warts := tf.Warts[1]
+        if len(warts) != 1 || warts[0].Reporter != "lintblame" || warts[0].IssueCode != "read-error" {
+            t.Errorf("Expected a read-error wart for %s, got %v", tf.Path, tf.Warts)
+        }
+    }
+}
+
+func TestGroupConsecutive(t *testing.T) {
+    tf := TargetFile{config: &config, Blames: make(map[int]BlameInfo)}
+    for i, name := range []string{"alice", "alice", "alice", "bob", "bob", "alice"} {
+        tf.Blames[i+1] = BlameInfo{Commit: "abc123", Name: name}
+    }
+    groups := groupConsecutive(&tf, []int{1, 2, 3, 4, 6})
+    expected := "[[1 2 3] [4] [6]]"
+    if fmt.Sprint(groups) != expected {
+        t.Errorf("Expected %s, got %v", expected, groups)
+    }
+}
+
+func TestGoBuildColumns(t *testing.T) {
+    out := "# example.com/x\n./at.go:21:11: undefined: foo\nvet: ./at.go:3: old style\n"
+    parsed := rexes["goBuild"].FindAllStringSubmatch(out, -1)
+    if len(parsed) != 2 {
+        t.Fatalf("Expected 2 findings, got %q", parsed)
+    }
+    if parsed[0][1] != "21" || parsed[0][2] != "11" || parsed[0][3] != "undefined: foo" {
+        t.Errorf("Unexpected parse with a column: %q", parsed[0])
+    }
+    if parsed[1][1] != "3" || parsed[1][2] != "" || parsed[1][3] != "old style" {
+        t.Errorf("Unexpected parse without a column: %q", parsed[1])
+    }
+    if detail := mustWart(t, "build", "21", "11", "-", "undefined: foo").Detail(); detail != "undefined: foo (col 11)" {
+        t.Errorf("Expected the column in the detail, got %q", detail)
+    }
+}
+
+func TestLintOutputStreams(t *testing.T) {
+    script := "echo 'a.go:3: progress on stdout'; echo 'a.go:4: finding on stderr' >&2"
+    combined, _, _ := lintOutput(context.Background(), exec.Command("sh", "-c", script), combinedStreams, 0)
+    parsed := rexes["goBuild"].FindAllStringSubmatch(combined, -1)
+    if len(parsed) != 2 || parsed[1][3] != "finding on stderr" {
+        t.Errorf("Expected stderr findings in combined output, got %q", combined)
+    }
+    stderr, _, _ := lintOutput(context.Background(), exec.Command("sh", "-c", script), stderrStream, 0)
+    if strings.TrimSpace(stderr) != "a.go:4: finding on stderr" {
+        t.Errorf("Expected only stderr, got %q", stderr)
+    }
+    stdout, _, _ := lintOutput(context.Background(), exec.Command("sh", "-c", script), stdoutStream, 0)
+    if strings.TrimSpace(stdout) != "a.go:3: progress on stdout" {
+        t.Errorf("Expected only stdout, got %q", stdout)
+    }
+}
+
+// Make a git repo with one committed file, returning the repo's path
+func makeRepo(t *testing.T, file string, content string) string {
+    if _, err := exec.LookPath("git"); err != nil {
+        t.Skip("git not installed")
+    }
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    dir, _ = filepath.EvalSymlinks(dir)
+    commitRepo(t, dir, "alice", file, content)
+    return dir
+}
+
+// NewWart for line and column numbers that are known to parse
+func mustWart(t *testing.T, reporter string, line string, column string, issueCode string, message string) Wart {
+    wart, err := NewWart(reporter, line, column, issueCode, message)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return wart
+}
+
+// Create a repo at dir with a single file committed by author
+func commitRepo(t *testing.T, dir string, author string, file string, content string) {
+    if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    for _, args := range [][]string{
+        {"init", "-q"},
+        {"add", file},
+        {"-c", "user.name=" + author, "-c", "user.email=" + author + "@example.com", "commit", "-q", "-m", "init"},
+    } {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v: %s", args, out)
+        }
+    }
+}
+
+func TestRelativeArgPaths(t *testing.T) {
+    repo := makeRepo(t, "sub/file.go", "package main\n\nfunc main() {\n\tx := 1\n}\n")
+    defer os.RemoveAll(repo)
+    if err := os.Symlink(filepath.Join(repo, "sub"), filepath.Join(repo, "link")); err != nil {
+        t.Fatal(err)
+    }
+    cwd, _ := os.Getwd()
+    defer os.Chdir(cwd)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"gobuild": true}
+    config.LinterJobs = 1
+
+    cases := []struct{ cwd, arg string }{
+        {repo, "./sub/file.go"},
+        {filepath.Join(repo, "sub"), "./file.go"},
+        {filepath.Join(repo, "sub"), "."},
+        {os.TempDir(), filepath.Join(repo, "sub", "file.go")},
+        {repo, "link/file.go"},
+    }
+    for _, c := range cases {
+        os.Chdir(c.cwd)
+        argPath, workingDir, err := resolveArgPath(c.arg)
+        if err != nil {
+            t.Fatalf("%s from %s: %s", c.arg, c.cwd, err)
+        }
+        config.ArgPaths, config.WorkingDir = []string{argPath}, workingDir
+        paths, err := config.argPathPaths()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(paths) != 1 || paths[0] != filepath.Join(repo, "sub", "file.go") {
+            t.Fatalf("%s from %s: unexpected paths %v", c.arg, c.cwd, paths)
+        }
+        tf, err := newTargetFile(context.Background(), &config, paths[0])
+        if err != nil {
+            t.Fatal(err)
+        }
+        if name := tf.BlameName(4); name != "alice" {
+            t.Errorf("%s from %s: expected blame alice, got %q", c.arg, c.cwd, name)
+        }
+        if len(tf.Warts) == 0 {
+            t.Errorf("%s from %s: expected go build to find the unused variable", c.arg, c.cwd)
+        }
+    }
+}
+
+func TestMultipleArgPaths(t *testing.T) {
+    repo := makeRepo(t, "one/a.go", "package one\n")
+    defer os.RemoveAll(repo)
+    commitRepo(t, repo, "bob", "two/b.py", "x = 1\n")
+    commitRepo(t, repo, "bob", "two/c.go", "package two\n")
+    oldConfig := config
+    defer func() { config = oldConfig }()
+
+    config.ArgPaths = []string{
+        filepath.Join(repo, "one"),
+        filepath.Join(repo, "two", "b.py"),
+        filepath.Join(repo, "one", "a.go"),
+    }
+    paths, err := config.argPathPaths()
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{filepath.Join(repo, "one", "a.go"), filepath.Join(repo, "two", "b.py")}
+    if strings.Join(paths, ",") != strings.Join(expected, ",") {
+        t.Errorf("Expected %v, got %v", expected, paths)
+    }
+
+    dirs := []string{filepath.Join(repo, "one"), filepath.Join(repo, "two"), filepath.Join(repo, "two", "three")}
+    if dir := commonDir(dirs); dir != repo {
+        t.Errorf("Expected %s to be the common dir, got %s", repo, dir)
+    }
+    if dir := commonDir([]string{"/a/bc", "/a/b"}); dir != "/a" {
+        t.Errorf("Expected /a to be the common dir, got %s", dir)
+    }
+}
+
+func TestFilterFilesExts(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = "/nowhere"
+    paths := config.filterFiles([]string{"x.py", "foo.xpyz", "a.bgo", "b.go", "c.pyc", "d.ts", "e.json", "f.rb", "Makefile"})
+    expected := []string{"/nowhere/x.py", "/nowhere/b.go", "/nowhere/d.ts", "/nowhere/f.rb"}
+    if strings.Join(paths, ",") != strings.Join(expected, ",") {
+        t.Errorf("Expected %v, got %v", expected, paths)
+    }
+}
+
+func TestFixRelints(t *testing.T) {
+    if _, err := exec.LookPath("gofmt"); err != nil {
+        t.Skip("gofmt not installed")
+    }
+    repo := makeRepo(t, "messy.go", "package messy\n\nvar  x = 1\n")
+    defer os.RemoveAll(repo)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = repo
+    config.LinterJobs = 1
+    config.Fix = true
+    config.Linters = map[string]bool{"gofmt": true}
+
+    path := filepath.Join(repo, "messy.go")
+    tf, err := newTargetFile(context.Background(), &config, path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(tf.Fixed) == 0 || tf.Fixed[0] != "gofmt" {
+        t.Errorf("Expected gofmt to fix the file, got %v", tf.Fixed)
+    }
+    if len(tf.Warts) != 0 {
+        t.Errorf("Expected no warts once fixed, got %v", tf.Warts)
+    }
+    if content, _ := ioutil.ReadFile(path); string(content) != "package messy\n\nvar x = 1\n" {
+        t.Errorf("Unexpected fixed content %q", content)
+    }
+
+    // Clean files aren't handed to the fixers at all
+    commitRepo(t, repo, "bob", "tidy.go", "package messy\n")
+    tf, err = newTargetFile(context.Background(), &config, filepath.Join(repo, "tidy.go"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(tf.Fixed) != 0 || len(tf.FixSkipped) != 0 {
+        t.Errorf("Expected the clean file to be left alone, got %v %q", tf.Fixed, tf.FixSkipped)
+    }
+}
+
+func TestReadPaths(t *testing.T) {
+    repo := makeRepo(t, "a.go", "package a\n")
+    defer os.RemoveAll(repo)
+    commitRepo(t, repo, "bob", "b.py", "x = 1\n")
+
+    input := filepath.Join(repo, "a.go") + "\n\n  " + filepath.Join(repo, "gone.py") + "\n" + filepath.Join(repo, "b.py")
+    paths := readPaths(strings.NewReader(input))
+    expected := []string{filepath.Join(repo, "a.go"), filepath.Join(repo, "b.py")}
+    if strings.Join(paths, ",") != strings.Join(expected, ",") {
+        t.Errorf("Expected %v, got %v", expected, paths)
+    }
+}
+
+func TestGoBuildInSubdir(t *testing.T) {
+    if _, err := exec.LookPath("go"); err != nil {
+        t.Skip("go not installed")
+    }
+    repo := makeRepo(t, "sub/deeper/file.go", "package main\n\nfunc main() {\n\tx := 1\n}\n")
+    defer os.RemoveAll(repo)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"gobuild": true}
+    config.WorkingDir = repo
+    path := filepath.Join(repo, "sub", "deeper", "file.go")
+    tf := &TargetFile{config: &config, Path: path, LintPath: path, Warts: make(map[int][]Wart)}
+    tf.GoBuild()
+    if len(tf.Warts) != 1 || len(tf.Warts[4]) != 1 {
+        t.Errorf("Expected just the unused variable, got %v", tf.Warts)
+    }
+    if _, err := os.Stat(filepath.Join(repo, "sub", "deeper", "file")); err == nil {
+        t.Error("Expected go build not to leave a binary behind")
+    }
+}
+
+func TestLintingKeepsCwd(t *testing.T) {
+    repo := makeRepo(t, "one/a.go", "package one\n")
+    defer os.RemoveAll(repo)
+    commitRepo(t, repo, "bob", "two/b.go", "package two\n")
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"gobuild": true, "govet": true}
+    config.WorkingDir = repo
+    config.Jobs = 2
+    config.LinterJobs = 2
+    cwd, _ := os.Getwd()
+    paths := []string{filepath.Join(repo, "one", "a.go"), filepath.Join(repo, "two", "b.go")}
+    for _, tf := range receiveFiles(lintFiles(context.Background(), &config, paths), len(paths)) {
+        if name := tf.BlameName(1); name != "alice" && name != "bob" {
+            t.Errorf("%s: expected it to be blamed, got %q", tf.Path, name)
+        }
+        if len(tf.Warts) > 0 {
+            t.Errorf("%s: expected it to be clean, got %v", tf.Path, tf.Warts)
+        }
+    }
+    if after, _ := os.Getwd(); after != cwd {
+        t.Errorf("Expected linting to leave the cwd at %s, got %s", cwd, after)
+    }
+}
+
+func TestBranchFilesWithoutBase(t *testing.T) {
+    repo := makeRepo(t, "a.py", "a = 1\n")
+    defer os.RemoveAll(repo)
+    commitRepo(t, repo, "bob", "b.go", "package b\n")
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = repo
+    for _, base := range []string{"", "no-such-branch"} {
+        config.BaseBranch = base
+        paths, err := config.gitBranchFiles()
+        if err != nil {
+            t.Fatal(err)
+        }
+        expected := filepath.Join(repo, "a.py") + "," + filepath.Join(repo, "b.go")
+        if got := strings.Join(paths, ","); got != expected {
+            t.Errorf("Base %q: expected every tracked file, got %v", base, paths)
+        }
+    }
+    if branch, err := env.CurrentGitBranch(repo); err != nil || len(branch) == 0 {
+        t.Errorf("Expected a branch, got %q and %v", branch, err)
+    }
+}
+
+func TestLinterExitCodes(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    codes, err := parseExitCodes("fake=0:1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    config.ExitCodes = codes
+    if !config.ExitCodes["pylint"][4] || config.ExitCodes["pylint"][32] {
+        t.Error("Expected pylint defaults to allow 4 but not 32")
+    }
+
+    tf := TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    tf.runLinter("fake", exec.Command("sh", "-c", "exit 1"), stdoutStream)
+    if len(tf.Warts) != 0 {
+        t.Errorf("Expected exit 1 to count as findings, got %v", tf.Warts)
+    }
+    tf.runLinter("fake", exec.Command("sh", "-c", "echo boom >&2; exit 2"), stdoutStream)
+    warts := tf.Warts[1]
+    if len(warts) != 1 || warts[0].IssueCode != "linter-error" || !strings.Contains(warts[0].Message, "boom") {
+        t.Errorf("Expected a linter-error wart, got %v", tf.Warts)
+    }
+}
+
+func TestLinterTimeout(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.LinterTimeout = 100 * time.Millisecond
+    tf := TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    start := time.Now()
+    tf.runLinter("fake", exec.Command("sh", "-c", "sleep 5"), stdoutStream)
+    if elapsed := time.Since(start); elapsed > 3*time.Second {
+        t.Errorf("Expected the linter to be killed, but it ran for %s", elapsed)
+    }
+    warts := tf.Warts[1]
+    if len(warts) != 1 || !strings.Contains(warts[0].Message, "fake timed out after 100ms") {
+        t.Errorf("Expected a timeout wart, got %v", tf.Warts)
+    }
+    if _, _, err := lintOutput(context.Background(), exec.Command("sh", "-c", "exit 1"), stdoutStream, time.Second); err == errLinterTimeout {
+        t.Errorf("Expected a quick failure not to count as a timeout")
+    }
+}
+
+func TestNestedRepoBlame(t *testing.T) {
+    outer := makeRepo(t, "outer.go", "package outer\n")
+    defer os.RemoveAll(outer)
+    inner := filepath.Join(outer, "vendor", "inner")
+    commitRepo(t, inner, "bob", "inner.go", "package inner\n")
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = outer
+    config.Linters = map[string]bool{}
+    config.LinterJobs = 1
+    for file, want := range map[string]string{
+        filepath.Join(outer, "outer.go"): "alice",
+        filepath.Join(inner, "inner.go"): "bob",
+    } {
+        tf, err := newTargetFile(context.Background(), &config, file)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := tf.BlameName(1); got != want {
+            t.Errorf("%s blamed on %q, want %q", file, got, want)
+        }
+        if got, want := gitRootFor(file), filepath.Dir(file); got != want {
+            t.Errorf("%s has git root %q, want %q", file, got, want)
+        }
+    }
+}
+
+func TestFormatChecks(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    cases := []struct {
+        linter  string
+        file    string
+        content string
+        want    int
+    }{
+        {"gofmt", "fmt.go", "package main\n", 0},
+        {"gofmt", "fmt.go", "package main\nvar  x = 1\n", 1},
+        {"black", "fmt.py", "x = 1\n", 0},
+        {"black", "fmt.py", "x = [1,2]\n", 1},
+        {"isort", "imports.py", "import os\nimport sys\n", 0},
+        {"isort", "imports.py", "import sys\nimport os\n", 1},
+    }
+    for _, c := range cases {
+        linter := lookupLinter(c.linter)
+        if _, err := exec.LookPath(linter.Binary()); err != nil {
+            t.Logf("%s not installed, skipping", c.linter)
+            continue
+        }
+        config.Linters = map[string]bool{c.linter: true}
+        path := filepath.Join(dir, c.file)
+        if err := ioutil.WriteFile(path, []byte(c.content), 0644); err != nil {
+            t.Fatal(err)
+        }
+        tf := &TargetFile{config: &config, Path: path, LintPath: path, Warts: make(map[int][]Wart)}
+        linter.Run(tf)
+        if got := len(tf.Warts[1]); got != c.want {
+            t.Errorf("Expected %d %s warts for %q, got %v", c.want, c.linter, c.content, tf.Warts)
+        }
+    }
+}
+
+func TestPrintLimit(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.PrintLimit = 2
+    config.NoColor = true
+    tf := &TargetFile{config: &config, Path: "limit.py", Warts: make(map[int][]Wart)}
+    for line := 1; line <= 5; line++ {
+        tf.ContentLines = append(tf.ContentLines, "x = 1")
+        tf.AddWart(Wart{Reporter: "PEP8", Line: line, IssueCode: "E1", Message: "bad"})
+    }
+    var out strings.Builder
+    printWarts(&out, tf)
+    if got := strings.Count(out.String(), "[PEP8 E1]"); got != 2 {
+        t.Errorf("Expected 2 lines printed, got %d:\n%s", got, out.String())
+    }
+    if !strings.Contains(out.String(), "...and 3 more lines with warts") {
+        t.Errorf("Expected a summary of the hidden lines, got:\n%s", out.String())
+    }
+    if !strings.HasPrefix(out.String(), "limit.py (5 issues)\n") {
+        t.Errorf("Expected the header to count every wart, got:\n%s", out.String())
+    }
+}
+
+func TestQuiet(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Quiet = true
+    config.NoColor = true
+    config.Format = "text"
+    clean := &TargetFile{config: &config, Path: "clean.py", ContentLines: []string{"x = 1"}, Warts: make(map[int][]Wart)}
+    if out := renderFile(clean).out.String(); len(out) > 0 {
+        t.Errorf("Expected nothing for a clean file, got:\n%s", out)
+    }
+    dirty := &TargetFile{config: &config, Path: "dirty.py", ContentLines: []string{"x = 1"}, Warts: make(map[int][]Wart)}
+    dirty.AddWart(Wart{Reporter: "PEP8", Line: 1, IssueCode: "E1", Message: "bad"})
+    if out := renderFile(dirty).out.String(); !strings.HasPrefix(out, "dirty.py (1 issue)\n") {
+        t.Errorf("Expected the file with warts to be printed, got:\n%s", out)
+    }
+}
+
+func TestOutsideGit(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    dir, _ = filepath.EvalSymlinks(dir)
+    if root := gitRootFor(filepath.Join(dir, "scratch.py")); len(root) > 0 {
+        t.Skipf("%s is inside the git repo at %s", dir, root)
+    }
+    path := filepath.Join(dir, "scratch.py")
+    if err := ioutil.WriteFile(path, []byte("x = 1\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = dir
+    config.ArgPaths = []string{dir}
+    config.LinterJobs = 1
+    config.Linters = map[string]bool{}
+
+    if paths, _ := config.targetPaths(); len(paths) != 1 || paths[0] != path {
+        t.Errorf("Expected to find %s, got %v", path, paths)
+    }
+    tf, err := newTargetFile(context.Background(), &config, path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if name := tf.BlameName(1); name != "-" {
+        t.Errorf("Expected no blame outside git, got %q", name)
+    }
+}
+
+func TestLint(t *testing.T) {
+    if _, err := exec.LookPath("gofmt"); err != nil {
+        t.Skip("gofmt isn't installed")
+    }
+    repo := makeRepo(t, "main.go", "package main\nfunc main() {}\n")
+    defer os.RemoveAll(repo)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    // As a library caller sees it, with no flags parsed
+    config = Config{}
+
+    files, err := Lint([]string{repo}, Options{Linters: []string{"gofmt"}, Jobs: 1})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(files) != 1 || len(files[0].Warts[1]) != 1 || files[0].Warts[1][0].Reporter != "gofmt" {
+        t.Fatalf("Expected a gofmt wart on main.go, got %v", files)
+    }
+    if name := files[0].BlameName(1); name != "alice" {
+        t.Errorf("Expected the line to be blamed on alice, got %q", name)
+    }
+    if _, err := Lint([]string{repo}, Options{Linters: []string{"nope"}}); err == nil {
+        t.Error("Expected an unknown linter to be an error")
+    }
+    if _, err := Lint([]string{filepath.Join(repo, "missing")}, Options{}); err == nil {
+        t.Error("Expected a missing path to be an error")
+    }
+    if _, err := Lint([]string{repo}, Options{Include: []string{"["}}); err == nil {
+        t.Error("Expected a bad glob to be an error")
+    }
+    if config.WorkingDir != "" || config.Jobs != 0 {
+        t.Error("Expected Lint to leave the command's configuration alone")
+    }
+
+    // The files keep the options they were linted with
+    summary := Summary{}
+    summary.Add(files[0])
+    if summary.Warts() != 1 {
+        t.Errorf("Expected the summary to count the gofmt wart, got %+v", summary)
+    }
+    tf, err := NewTargetFile(filepath.Join(repo, "main.go"), Options{Linters: []string{"gofmt"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(tf.Warts[1]) != 1 || tf.BlameName(1) != "alice" {
+        t.Errorf("Expected NewTargetFile to lint and blame main.go, got %v", tf.Warts)
+    }
+}
+
+func TestParseWart(t *testing.T) {
+    if _, err := NewWart("PEP8", "x", "1", "E1", "bad"); err == nil {
+        t.Error("Expected a bad line number to be an error")
+    }
+    tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    if _, ok := tf.parseWart("PEP8", "1", "y", "E1", "bad"); ok {
+        t.Error("Expected a bad column number to be rejected")
+    }
+    if wart := tf.Warts[1]; len(wart) != 1 || wart[0].IssueCode != "linter-error" {
+        t.Errorf("Expected a linter error instead, got %v", tf.Warts)
+    }
+}
+
+func TestExitStatus(t *testing.T) {
+    cases := []struct {
+        summary  Summary
+        expected int
+    }{
+        {Summary{}, 0},
+        {Summary{Warnings: 2}, exitWarts},
+        {Summary{Errors: 1, Internal: 1}, exitInternal},
+        {Summary{Errors: 1, Truncated: true}, exitTruncated},
+    }
+    for _, c := range cases {
+        if status := exitStatus(c.summary); status != c.expected {
+            t.Errorf("Expected %d for %+v, got %d", c.expected, c.summary, status)
+        }
+    }
+}
+
+func TestStats(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    summary := Summary{Files: 12, Dirty: 2, Errors: 1, Warnings: 4}
+    cases := map[string]string{
+        "text": "12 files: 10 clean, 2 dirty, 5 warts\n",
+        "kv":   "lintblame: stats files=12 clean=10 dirty=2 warts=5\n",
+        "json": `{"files":12,"clean":10,"dirty":2,"warts":5}` + "\n",
+    }
+    for format, expected := range cases {
+        config.Format = format
+        var out strings.Builder
+        printStats(&out, summary)
+        if out.String() != expected {
+            t.Errorf("%s: expected %q, got %q", format, expected, out.String())
+        }
+    }
+}
+
+func TestSinceCommitAcrossRepos(t *testing.T) {
+    first := makeRepo(t, "a.go", "package a\n")
+    defer os.RemoveAll(first)
+    second := makeRepo(t, "b.go", "package b\n")
+    defer os.RemoveAll(second)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = first
+    config.SinceCommit = "HEAD"
+
+    for _, repo := range []string{first, second} {
+        out, err := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if commit := strings.TrimSpace(string(out)); !config.isAncestor(repo, commit) {
+            t.Errorf("Expected %s's HEAD to be contained in its own HEAD", repo)
+        }
+    }
+}
+
+func TestAtRevAcrossRepos(t *testing.T) {
+    first := makeRepo(t, "a.go", "package a\n")
+    defer os.RemoveAll(first)
+    second := makeRepo(t, "b.go", "package b\n")
+    defer os.RemoveAll(second)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = first
+    config.AtRev = "HEAD"
+    config.Linters = map[string]bool{"govet": true}
+    config.LinterJobs = 1
+
+    for _, file := range []string{filepath.Join(first, "a.go"), filepath.Join(second, "b.go")} {
+        committed, err := ioutil.ReadFile(file)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := ioutil.WriteFile(file, []byte("package changed\n"), 0644); err != nil {
+            t.Fatal(err)
+        }
+        tf, err := newTargetFile(context.Background(), &config, file)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := strings.Join(tf.ContentLines, "\n"); !strings.HasPrefix(string(committed), got) {
+            t.Errorf("Expected %s as it was at HEAD, got %q", file, got)
+        }
+        if got := tf.BlameName(1); got != "alice" {
+            t.Errorf("%s blamed on %q, want alice", file, got)
+        }
+        for _, status := range tf.Linters {
+            if status.Name == "govet" && (status.Ran || status.Reason == "disabled") {
+                t.Errorf("Expected govet to be skipped with -at, got %+v", status)
+            }
+        }
+    }
+}
+
+func TestDisplayPath(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = "/src/project"
+    cases := []struct {
+        path     string
+        abs      bool
+        expected string
+    }{
+        {"/src/project/main.go", false, "main.go"},
+        {"/src/project/cmd/server/main.go", false, "cmd/server/main.go"},
+        {"/src/other/main.go", false, "/src/other/main.go"},
+        {"/src/project/main.go", true, "/src/project/main.go"},
+    }
+    for _, c := range cases {
+        config.AbsPaths = c.abs
+        if path := config.displayPath(c.path); path != c.expected {
+            t.Errorf("Expected %s to display as %s with -abs=%v, got %s", c.path, c.expected, c.abs, path)
+        }
+    }
+}
+
+func TestLinePastEOF(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.NoColor = true
+    tf := &TargetFile{config: &config, Path: "vet.go", ContentLines: []string{"package main", ""}, Warts: make(map[int][]Wart)}
+    tf.AddWart(Wart{Reporter: "vet", Line: 3, IssueCode: "-", Message: "missing newline"})
+    var out strings.Builder
+    printWarts(&out, tf)
+    if expected := "3: (-) [line not in file]"; !strings.Contains(out.String(), expected) {
+        t.Errorf("Expected %q in %q", expected, out.String())
+    }
+}
+
+func TestTUISeverityFilter(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 1, IssueCode: "E1", Severity: SeverityInfo})
+    tf.AddWart(Wart{Reporter: "vet", Line: 2, IssueCode: "-", Severity: SeverityWarning})
+    tf.AddWart(Wart{Reporter: "build", Line: 3, IssueCode: "-", Severity: SeverityError})
+    ui := &tui{files: []*TargetFile{tf}}
+    for _, expected := range []int{2, 1, 3} {
+        ui.cycleSeverity()
+        if visible := ui.visibleWarts(tf); len(visible) != expected {
+            t.Errorf("Severity %s: expected %d lines, got %v", ui.severity, expected, visible)
+        }
+    }
+}
+
+func TestTUILog(t *testing.T) {
+    screen := tcell.NewSimulationScreen("")
+    if err := screen.Init(); err != nil {
+        t.Fatal(err)
+    }
+    defer screen.Fini()
+    logs := &tuiLog{screen: screen}
+    logger := log.New(logs, "", 0)
+    logger.Printf("golint failed")
+    ev, ok := screen.PollEvent().(*logEvent)
+    if !ok || ev.line != "golint failed" {
+        t.Errorf("Expected the line posted to the screen, got %+v", ev)
+    }
+    if got := logs.buf.String(); got != "golint failed\n" {
+        t.Errorf("Expected the line kept for after the screen closes, got %q", got)
+    }
+}
+
+func TestNoBlame(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.NoBlame = true
+    config.NoColor = true
+    tf := &TargetFile{config: &config, Path: "scratch.py", ContentLines: []string{"x = 1", "y = 2"}, Warts: make(map[int][]Wart)}
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 2, IssueCode: "E1", Message: "bad"})
+    var out strings.Builder
+    printWarts(&out, tf)
+    if expected := "scratch.py (1 issue)\n2: y = 2\n    [PEP8 E1] bad\n"; out.String() != expected {
+        t.Errorf("Expected %q, got %q", expected, out.String())
+    }
+}
+
+func TestIgnore(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    ignore, err := parseIgnore("pep8:E501, Pylint:C0111")
+    if err != nil {
+        t.Fatal(err)
+    }
+    config.Ignore = ignore
+    tf := &TargetFile{config: &config, Path: "ignore.py", Warts: make(map[int][]Wart)}
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 1, IssueCode: "E501", Message: "line too long"})
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 1, IssueCode: "E302", Message: "expected 2 blank lines"})
+    tf.AddWart(Wart{Reporter: "Pylint", Line: 2, IssueCode: "C0111", Message: "missing docstring"})
+    if len(tf.Warts[1]) != 1 || tf.Warts[1][0].IssueCode != "E302" || len(tf.Warts[2]) != 0 {
+        t.Errorf("Expected only E302 to be kept, got %v", tf.Warts)
+    }
+    for _, bad := range []string{"E501", "pep8:", ":E501"} {
+        if _, err := parseIgnore(bad); err == nil {
+            t.Errorf("Expected %q to be rejected", bad)
+        }
+    }
+
+    config.Ignore, _ = parseIgnore("pylint:C0111,govet:printf,vet:printf,lintblame:linter-error")
+    config.Linters = map[string]bool{}
+    if unknown := config.unknownReporters(); strings.Join(unknown, ",") != "govet" {
+        t.Errorf("Expected govet to be unknown, its warts being vet's, got %v", unknown)
+    }
+    config.Linters = map[string]bool{"golangci-lint": true}
+    if unknown := config.unknownReporters(); len(unknown) != 0 {
+        t.Errorf("Expected anything to go with golangci-lint enabled, got %v", unknown)
+    }
+}
+
+func TestPyLint(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    output := "************* Module x\n1,0:C0111:Missing module docstring\n3,4:W0612:Unused variable y"
+    defer fakeLinter(t, dir, "pylint", output, 16)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"pylint": true}
+    config.ExitCodes = map[string]map[int]bool{"pylint": {16: true}}
+    config.Ignore, _ = parseIgnore("pylint:C0111")
+    file := filepath.Join(dir, "x.py")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.PyLint()
+    if len(tf.Warts) != 1 || len(tf.Warts[3]) != 1 {
+        t.Fatalf("Expected just the line 3 wart, C0111 being ignored, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[3][0]; wart.IssueCode != "W0612" || wart.Column != 4 || wart.Severity != SeverityWarning {
+        t.Errorf("Unexpected wart %+v", wart)
+    }
+}
+
+func TestSplitLines(t *testing.T) {
+    lines := splitLines("x = 1\r\ny = 2\nz = '\r'\r\n")
+    expected := []string{"x = 1", "y = 2", "z = '\r'", ""}
+    if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+        t.Errorf("Expected %q, got %q", expected, lines)
+    }
+}
+
+func TestContext(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Context = 2
+    config.NoBlame = true
+    config.NoColor = true
+    tf := &TargetFile{config: &config, Path: "ctx.py", ContentLines: []string{"def f():", "    x = 1", "    return x  ", ""}, Warts: make(map[int][]Wart)}
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 2, IssueCode: "E1", Message: "bad"})
+    var out strings.Builder
+    printWarts(&out, tf)
+    expected := "ctx.py (1 issue)\n1- def f():\n2:     x = 1\n3-     return x\n    [PEP8 E1] bad\n"
+    if out.String() != expected {
+        t.Errorf("Expected %q, got %q", expected, out.String())
+    }
+}
+
+func TestContextOverlap(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Context = 2
+    config.NoBlame = true
+    config.NoColor = true
+    tf := &TargetFile{config: &config, Path: "ctx.py", ContentLines: []string{"a", "b", "c", "d", "e", "f", "g", ""}, Warts: make(map[int][]Wart)}
+    for _, line := range []int{2, 3, 6} {
+        tf.AddWart(Wart{Reporter: "PEP8", Line: line, IssueCode: "E1", Message: "bad"})
+    }
+    var out strings.Builder
+    printWarts(&out, tf)
+    // Each line is printed once, and wart lines never as context
+    expected := strings.Join([]string{
+        "ctx.py (3 issues)",
+        "1- a", "2: b", "    [PEP8 E1] bad",
+        "3: c", "4- d", "5- e", "    [PEP8 E1] bad",
+        "6: f", "7- g", "    [PEP8 E1] bad",
+    }, "\n") + "\n"
+    if out.String() != expected {
+        t.Errorf("Expected %q, got %q", expected, out.String())
+    }
+}
+
+func TestLineRanges(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    ranges, err := parseLineRanges("3-4, 7")
+    if err != nil {
+        t.Fatal(err)
+    }
+    config.Lines = ranges
+    tf := &TargetFile{config: &config, Path: "lines.py", Warts: make(map[int][]Wart)}
+    for line := 1; line <= 8; line++ {
+        tf.AddWart(Wart{Reporter: "PEP8", Line: line, IssueCode: "E1", Message: "bad"})
+    }
+    lines := make([]string, 0)
+    for _, line := range sortedLines(filterWarts(tf)) {
+        lines = append(lines, strconv.Itoa(line))
+    }
+    if got := strings.Join(lines, ","); got != "3,4,7" {
+        t.Errorf("Expected warts on lines 3, 4 and 7, got %s", got)
+    }
+    for _, bad := range []string{"", "0", "5-2", "a-b", "1,,2"} {
+        if _, err := parseLineRanges(bad); err == nil {
+            t.Errorf("Expected %q to be rejected", bad)
+        }
+    }
+}
+
+func TestGroupByReporter(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.GroupBy = "reporter"
+    config.NoColor = true
+    tf := &TargetFile{config: &config, Path: "group.py", Warts: make(map[int][]Wart)}
+    for line := 1; line <= 3; line++ {
+        tf.ContentLines = append(tf.ContentLines, "x = 1")
+    }
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 3, IssueCode: "E1", Message: "late"})
+    tf.AddWart(Wart{Reporter: "Pylint", Line: 1, IssueCode: "C", Message: "pylint says"})
+    tf.AddWart(Wart{Reporter: "PEP8", Line: 1, IssueCode: "E2", Message: "early"})
+    var out strings.Builder
+    printWarts(&out, tf)
+    expected := "group.py (3 issues)\n" +
+        "PEP8\n" +
+        "    1: (-) [E2] early\n" +
+        "    3: (-) [E1] late\n" +
+        "Pylint\n" +
+        "    1: (-) [C] pylint says\n"
+    if out.String() != expected {
+        t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+    }
+}
+
+func TestParseBlame(t *testing.T) {
+    out := strings.Join([]string{
+        "1234567890abcdef1234567890abcdef12345678 1 1 2",
+        "author Zoë Smith-Jones",
+        "author-mail <zoe@example.com>",
+        "author-time 1680350400",
+        "summary init",
+        "\tpackage main",
+        "1234567890abcdef1234567890abcdef12345678 2 2",
+        "author Zoë Smith-Jones",
+        "summary init",
+        "\t",
+        "0000000000000000000000000000000000000000 3 3 1",
+        "author Not Committed Yet",
+        "\tfunc main() {}",
+        "",
+    }, "\n")
+    blames := parseBlame(out)
+    if len(blames) != 3 {
+        t.Fatalf("Expected 3 blamed lines, got %v", blames)
+    }
+    if blames[2].Name != "Zoë Smith-Jones" || blames[2].Commit != "1234567890abcdef1234567890abcdef12345678" {
+        t.Errorf("Unexpected blame for line 2: %+v", blames[2])
+    }
+    tf := TargetFile{config: &config, Blames: blames}
+    if label := tf.BlameLabel(1); label != "Zoë Smith-Jones, 1234567, 2023-04-01" {
+        t.Errorf("Unexpected blame label for line 1: %q", label)
+    }
+    if name := tf.BlameName(3); name != "uncommitted" {
+        t.Errorf("Expected line 3 to be uncommitted, got %q", name)
+    }
+    if name := tf.BlameName(4); name != "-" {
+        t.Errorf("Expected no blame past the end of the file, got %q", name)
+    }
+}
+
+func TestGitRetry(t *testing.T) {
+    if _, err := exec.LookPath("sh"); err != nil {
+        t.Skip("sh not installed")
+    }
+    oldDelays := gitRetryDelays
+    defer func() { gitRetryDelays = oldDelays }()
+    gitRetryDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+
+    attempts := 0
+    locked := func() *exec.Cmd {
+        attempts++
+        if attempts < 3 {
+            return exec.Command("sh", "-c", "echo \"fatal: Unable to create '/repo/.git/index.lock': File exists.\" >&2; exit 128")
+        }
+        return exec.Command("sh", "-c", "echo ok")
+    }
+    out, err := gitRetry(locked)
+    if err != nil || string(out) != "ok\n" || attempts != 3 {
+        t.Errorf("Expected ok on the third attempt, got %q, %v after %d", out, err, attempts)
+    }
+
+    attempts = 0
+    broken := func() *exec.Cmd {
+        attempts++
+        return exec.Command("sh", "-c", "echo 'fatal: not a git repository' >&2; exit 128")
+    }
+    if _, err := gitRetry(broken); err == nil || attempts != 1 {
+        t.Errorf("Expected other failures not to be retried, got %v after %d", err, attempts)
+    }
+}
+
+func TestLabelUnstaged(t *testing.T) {
+    repo := makeRepo(t, "a.py", "a = 1\nb = 2\nc = 3\n")
+    defer os.RemoveAll(repo)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    path := filepath.Join(repo, "a.py")
+    if err := ioutil.WriteFile(path, []byte("a = 1\nb = 20\nc = 3\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    cmd := exec.Command("git", "add", "a.py")
+    cmd.Dir = repo
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("git add: %s", out)
+    }
+    if err := ioutil.WriteFile(path, []byte("a = 1\nb = 20\nc = 30\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    config.LabelUnstaged = true
+    tf := &TargetFile{config: &config, Path: path}
+    tf.Blame()
+    for line, expected := range map[int]string{1: "alice", 2: "you (staged)", 3: "you (unstaged)"} {
+        if name := tf.BlameName(line); name != expected {
+            t.Errorf("Expected line %d to be blamed on %q, got %q", line, expected, name)
+        }
+    }
+    if !config.isMe(tf.BlameName(3)) {
+        t.Error("Expected unstaged lines to count as yours")
+    }
+}
+
+func TestParseDiffLines(t *testing.T) {
+    out := "diff --git a/x.py b/x.py\n--- a/x.py\n+++ b/x.py\n" +
+        "@@ -3 +3 @@\n-a\n+b\n" +
+        "@@ -7,0 +8,2 @@\n+c\n+d\n" +
+        "@@ -20,2 +21,0 @@\n-e\n-f\n"
+    changed := parseDiffLines(out)
+    if len(changed) != 3 || !changed[3] || !changed[8] || !changed[9] {
+        t.Errorf("Expected lines 3, 8 and 9, got %v", changed)
+    }
+}
+
+func TestDiffOnly(t *testing.T) {
+    repo := makeRepo(t, "x.py", "a = 1\nb = 2\nc = 3\n")
+    defer os.RemoveAll(repo)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.DiffOnly = true
+    path := filepath.Join(repo, "x.py")
+    if err := ioutil.WriteFile(path, []byte("a = 1\nb = 22\nc = 3\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    tf := &TargetFile{config: &config, Path: path, Warts: make(map[int][]Wart)}
+    tf.Blame()
+    tf.Diff()
+    for line := 1; line <= 3; line++ {
+        tf.AddWart(mustWart(t, "PEP8", strconv.Itoa(line), "1", "E1", "bad"))
+    }
+    warts := filterWarts(tf)
+    if len(warts) != 1 || len(warts[2]) != 1 {
+        t.Errorf("Expected only the changed line's wart, got %v", warts)
+    }
+
+    untracked := filepath.Join(repo, "new.py")
+    ioutil.WriteFile(untracked, []byte("x = 1\n"), 0644)
+    tf = &TargetFile{config: &config, Path: untracked, Warts: make(map[int][]Wart)}
+    tf.Blame()
+    tf.Diff()
+    tf.AddWart(mustWart(t, "PEP8", "1", "1", "E1", "bad"))
+    if warts := filterWarts(tf); len(warts) != 1 {
+        t.Errorf("Expected an untracked file's warts to all show, got %v", warts)
+    }
+}
+
+func TestSARIFReport(t *testing.T) {
+    repo := makeRepo(t, "pkg/x.py", "import os\n")
+    defer os.RemoveAll(repo)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    tf := &TargetFile{config: &config, Path: filepath.Join(repo, "pkg", "x.py"), Warts: make(map[int][]Wart)}
+    tf.Blame()
+    tf.AddWart(mustWart(t, "Pylint", "1", "0", "W0611", "Unused import os"))
+    tf.AddWart(mustWart(t, "vet", "1", "3", "-", "something"))
+
+    start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+    summary := Summary{Files: 1, Total: 1, Warnings: 2, Timestamp: start, Duration: 1500 * time.Millisecond}
+    out, err := json.Marshal(sarifReport([]*TargetFile{tf}, summary))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var report struct {
+        Version string
+        Runs    []struct {
+            Tool        struct{ Driver struct{ Rules []struct{ ID string } } }
+            Invocations []struct {
+                ExecutionSuccessful bool
+                ExitCode            int
+                StartTimeUTC        string
+                EndTimeUTC          string
+            }
+            Properties struct{ Files, Warnings int }
+            Results    []struct {
+                RuleID    string
+                Level     string
+                Locations []struct {
+                    PhysicalLocation struct {
+                        ArtifactLocation struct{ URI string }
+                        Region           struct{ StartLine, StartColumn int }
+                    }
+                }
+                Properties map[string]string
+            }
+        }
+    }
+    if err := json.Unmarshal(out, &report); err != nil {
+        t.Fatal(err)
+    }
+    if report.Version != "2.1.0" || len(report.Runs) != 1 || len(report.Runs[0].Results) != 2 {
+        t.Fatalf("Unexpected report %s", out)
+    }
+    run := report.Runs[0]
+    if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "Pylint/W0611" || run.Tool.Driver.Rules[1].ID != "vet" {
+        t.Errorf("Unexpected rules %+v", run.Tool.Driver.Rules)
+    }
+    result := run.Results[0]
+    location := result.Locations[0].PhysicalLocation
+    if result.Level != "warning" || location.ArtifactLocation.URI != "pkg/x.py" || location.Region.StartLine != 1 {
+        t.Errorf("Unexpected result %+v", result)
+    }
+    if result.Properties["blameName"] != "alice" {
+        t.Errorf("Expected the blame name in the result's properties, got %v", result.Properties)
+    }
+    if len(run.Invocations) != 1 {
+        t.Fatalf("Expected one invocation, got %s", out)
+    }
+    invocation := run.Invocations[0]
+    if !invocation.ExecutionSuccessful || invocation.ExitCode != exitWarts ||
+        invocation.StartTimeUTC != "2020-01-02T03:04:05Z" || invocation.EndTimeUTC != "2020-01-02T03:04:06.5Z" {
+        t.Errorf("Unexpected invocation %+v", invocation)
+    }
+    if run.Properties.Files != 1 || run.Properties.Warnings != 2 {
+        t.Errorf("Expected the run's counts in its properties, got %s", out)
+    }
+}
+
+func TestJSONRun(t *testing.T) {
+    tf := &TargetFile{config: &config, Path: "/src/x.py", Warts: make(map[int][]Wart)}
+    tf.AddWart(mustWart(t, "PEP8", "3", "80", "E501", "line too long"))
+    tf.AddWart(mustWart(t, "build", "1", "0", "-", "broken"))
+    summary := Summary{Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Duration: 1500 * time.Millisecond}
+    summary.Add(tf)
+    out, err := json.Marshal(jsonRun([]renderedFile{{warts: jsonWarts(tf)}}, summary))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var report struct {
+        Warts   []struct{ Line int }
+        Summary struct {
+            Files, Errors, Warnings, Infos int
+            DurationMs                     int
+            Timestamp                      string
+        }
+    }
+    if err := json.Unmarshal(out, &report); err != nil {
+        t.Fatal(err)
+    }
+    if len(report.Warts) != 2 || report.Warts[0].Line != 1 {
+        t.Errorf("Expected both warts in line order, got %s", out)
+    }
+    s := report.Summary
+    if s.Files != 1 || s.Errors != 1 || s.Warnings != 0 || s.Infos != 1 || s.DurationMs != 1500 || s.Timestamp != "2020-01-02T03:04:05Z" {
+        t.Errorf("Unexpected summary %s", out)
+    }
+}
+
+func TestCheckstyleReport(t *testing.T) {
+    clean := &TargetFile{config: &config, Path: "/src/clean.go", Warts: make(map[int][]Wart)}
+    dirty := &TargetFile{config: &config, Path: "/src/dirty.py", Warts: make(map[int][]Wart)}
+    dirty.AddWart(mustWart(t, "PEP8", "3", "80", "E501", "line too long & then some"))
+    dirty.AddWart(mustWart(t, "build", "1", "0", "-", "broken"))
+    out, err := xml.Marshal(checkstyle([]*TargetFile{clean, dirty}))
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := `<checkstyle version="4.3">` +
+        `<file name="/src/clean.go"></file>` +
+        `<file name="/src/dirty.py">` +
+        `<error line="1" severity="error" message="broken" source="build"></error>` +
+        `<error line="3" column="80" severity="info" message="[E501] line too long &amp; then some" source="PEP8"></error>` +
+        `</file></checkstyle>`
+    if string(out) != expected {
+        t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+    }
+}
+
+func TestIsLintPath(t *testing.T) {
+    tf := &TargetFile{config: &config, LintPath: "/src/pkg/a.go"}
+    out := "a.go:12:10:\tf.Close()\n./b.go:3:2:\tos.Remove(x)\n/src/pkg/a.go:20:1:\tw.Write(b)\n"
+    matched := 0
+    for _, group := range rexes["errcheck"].FindAllStringSubmatch(out, -1) {
+        if tf.isLintPath("/src/pkg", group[1]) {
+            matched++
+        }
+    }
+    if matched != 2 {
+        t.Errorf("Expected 2 findings in a.go, got %d", matched)
+    }
+}
+
+func TestRecursiveDirFiles(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    for _, file := range []string{"top.go", "sub/pkg/nested.py", "vendor/dep/dep.go", "node_modules/x/x.py", ".git/hooks/hook.py"} {
+        path := filepath.Join(dir, file)
+        if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+            t.Fatal(err)
+        }
+        if err := ioutil.WriteFile(path, []byte(""), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    oldConfig := config
+    defer func() { config = oldConfig }()
+
+    if files, _ := config.getDirFiles(dir); len(files) != 1 {
+        t.Errorf("Expected only the top level without -r, got %v", files)
+    }
+    config.Recursive = true
+    files, err := config.getDirFiles(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := fmt.Sprint([]string{filepath.Join(dir, "sub/pkg/nested.py"), filepath.Join(dir, "top.go")})
+    if fmt.Sprint(files) != expected {
+        t.Errorf("Expected %s, got %v", expected, files)
+    }
+}
+
+func TestIncludeExclude(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = "/repo"
+    config.Exclude = []string{"*_test.go", "migrations/*.py"}
+    for path, want := range map[string]bool{
+        "/repo/main.go":                     true,
+        "/repo/main_test.go":                false,
+        "/repo/pkg/util_test.go":            false,
+        "/repo/app/migrations/0001_init.py": false,
+        "/repo/app/models.py":               true,
+    } {
+        if got := config.included(path); got != want {
+            t.Errorf("config.included(%s) = %v, want %v", path, got, want)
+        }
+    }
+    config.Include = []string{"app/*"}
+    if config.included("/repo/main.go") || !config.included("/repo/app/models.py") {
+        t.Errorf("Expected -include to keep only app/*")
+    }
+}
+
+func TestGitignoredFiles(t *testing.T) {
+    repo := makeRepo(t, ".gitignore", "gen/\n*.pb.go\n")
+    defer os.RemoveAll(repo)
+    for _, file := range []string{"main.go", "api.pb.go", "gen/gen.go"} {
+        path := filepath.Join(repo, file)
+        if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+            t.Fatal(err)
+        }
+        if err := ioutil.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.WorkingDir = repo
+    config.Recursive = true
+    files, err := config.getDirFiles(repo)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(files) != 1 || files[0] != filepath.Join(repo, "main.go") {
+        t.Errorf("Expected only main.go, got %v", files)
+    }
+}
+
+func TestMyPyOutput(t *testing.T) {
+    out := "a.py:3:5: error: Incompatible types in assignment  [assignment]\na.py:7:1: note: See docs\na.py:9:2: error: Name \"x\" is not defined\n"
+    parsed := rexes["mypy"].FindAllStringSubmatch(out, -1)
+    if len(parsed) != 2 {
+        t.Fatalf("Expected 2 errors, got %q", parsed)
+    }
+    if parsed[0][3] != "Incompatible types in assignment" || parsed[0][4] != "assignment" {
+        t.Errorf("Unexpected parse of a coded error: %q", parsed[0])
+    }
+    if parsed[1][1] != "9" || parsed[1][4] != "" {
+        t.Errorf("Unexpected parse of an uncoded error: %q", parsed[1])
+    }
+}
+
+func TestGoCycloOutput(t *testing.T) {
+    out := "17 main (*TargetFile).Update /tmp/x/main.go:120:1\n16 main main /tmp/x/main.go:12:1\n"
+    parsed := rexes["gocyclo"].FindAllStringSubmatch(out, -1)
+    if len(parsed) != 2 {
+        t.Fatalf("Expected 2 functions, got %q", parsed)
+    }
+    if parsed[0][1] != "17" || parsed[0][3] != "(*TargetFile).Update" || parsed[0][4] != "120" || parsed[0][5] != "1" {
+        t.Errorf("Unexpected parse: %q", parsed[0])
+    }
+}
+
+func TestIneffAssignOutput(t *testing.T) {
+    out := "/tmp/x/main.go:7:2: ineffectual assignment to err\n/tmp/x/other.go:3:1: ineffectual assignment to n\n"
+    parsed := rexes["ineffassign"].FindAllStringSubmatch(out, -1)
+    if len(parsed) != 2 {
+        t.Fatalf("Expected 2 assignments, got %q", parsed)
+    }
+    if parsed[0][1] != "/tmp/x/main.go" || parsed[0][2] != "7" || parsed[0][4] != "ineffectual assignment to err" {
+        t.Errorf("Unexpected parse: %q", parsed[0])
+    }
+}
+
+func TestPyFlakesOutput(t *testing.T) {
+    out := "a.py:1:1: 'os' imported but unused\na.py:4: undefined name 'x'\na.py:7:5: invalid syntax\n    x = = 1\n        ^\n"
+    parsed := rexes["pyflakes"].FindAllStringSubmatch(out, -1)
+    if len(parsed) != 3 {
+        t.Fatalf("Expected 3 messages, got %q", parsed)
+    }
+    if parsed[0][1] != "1" || parsed[0][2] != "1" || parsed[0][3] != "'os' imported but unused" {
+        t.Errorf("Unexpected parse with a column: %q", parsed[0])
+    }
+    if parsed[1][1] != "4" || parsed[1][2] != "" || parsed[1][3] != "undefined name 'x'" {
+        t.Errorf("Unexpected parse without a column: %q", parsed[1])
+    }
+}
+
+func TestLintCacheByContent(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{}
+    config.LinterJobs = 1
+    path := filepath.Join(dir, "cached.py")
+    ioutil.WriteFile(path, []byte("x = 1\n"), 0644)
+    first := lintFile(context.Background(), &config, path)
+    if again := lintFile(context.Background(), &config, path); again != first {
+        t.Errorf("Expected unchanged content to reuse the last results")
+    }
+    ioutil.WriteFile(path, []byte("x = 2\n"), 0644)
+    if changed := lintFile(context.Background(), &config, path); changed == first {
+        t.Errorf("Expected changed content to be linted again")
+    }
+}
+
+func TestLintCacheInvalidation(t *testing.T) {
+    repo := makeRepo(t, "main.go", "package main\n")
+    defer os.RemoveAll(repo)
+    path := filepath.Join(repo, "main.go")
+    content := []byte("package main\n")
+    key := lintKey(path, content)
+
+    // A sibling in the same package changing can change go vet's results
+    sibling := filepath.Join(repo, "util.go")
+    if err := ioutil.WriteFile(sibling, []byte("package main\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    withSibling := lintKey(path, content)
+    if withSibling == key {
+        t.Error("Expected a new sibling to change the key")
+    }
+    if err := ioutil.WriteFile(filepath.Join(repo, "notes.txt"), []byte("hi\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if lintKey(path, content) != withSibling {
+        t.Error("Expected files with other extensions not to change the key")
+    }
+
+    // Staging or committing changes what blame and -label-unstaged say
+    cmd := exec.Command("git", "add", "util.go")
+    cmd.Dir = repo
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("git add: %s", out)
+    }
+    if lintKey(path, content) == withSibling {
+        t.Error("Expected git add to change the key")
+    }
+}
+
+func TestReporterCounts(t *testing.T) {
+    summary := Summary{}
+    for _, reporters := range [][]string{{"PEP8", "PEP8", "vet"}, {}, {"PEP8"}} {
+        tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+        for i, reporter := range reporters {
+            tf.AddWart(Wart{Reporter: reporter, Line: i + 1})
+        }
+        summary.Add(tf)
+    }
+    expected := "PEP8: 3, vet: 1, total: 4 across 2 files"
+    if got := summary.ReporterCounts(); got != expected {
+        t.Errorf("Expected %q, got %q", expected, got)
+    }
+    if summary.Warnings != 0 || summary.Infos != 4 {
+        t.Errorf("Expected info-level warts to count as infos, got %+v", summary)
+    }
+}
+
+func TestMissingLinters(t *testing.T) {
+    summary := Summary{}
+    tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    tf.Linters = []LinterStatus{
+        {Name: "pylint", Reason: "not installed"},
+        {Name: "pep8", Ran: true},
+    }
+    summary.Add(tf)
+    tf = &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    tf.Linters = []LinterStatus{
+        {Name: "golint", Reason: "not installed"},
+        {Name: "gosec", Reason: "disabled"},
+        {Name: "govet", Ran: true},
+    }
+    // Uninstalled between the PATH lookup and running it
+    tf.notInstalled("govet")
+    summary.Add(tf)
+    if got := strings.Join(summary.MissingLinters(), ","); got != "golint,govet,pylint" {
+        t.Errorf("Expected golint, govet and pylint to be missing, got %s", got)
+    }
+}
+
+func TestLinterDefaults(t *testing.T) {
+    for name, want := range map[string]bool{
+        "gofmt": true, "pylint": true, "pep8": true,
+        "gotest": false, "gosec": false, "golangci-lint": false, "flake8": false,
+    } {
+        if got := linterDefault(name); got != want {
+            t.Errorf("Expected %s to default to %v, got %v", name, want, got)
+        }
+    }
+}
+
+func TestWatchKeys(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Interval = time.Hour
+    config.Format = "kv"
+    linted.files["/gone.py"] = lintedFile{}
+    keys := make(chan string)
+    runs := 0
+    done := make(chan bool)
+    go func() {
+        watch(&ModifiedTimes{TimeMap: make(map[string]time.Time)}, keys, func(ModifiedTimes) { runs++ })
+        done <- true
+    }()
+    keys <- "r"
+    keys <- "c"
+    keys <- "q"
+    select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("Expected q to stop the watch")
+    }
+    if runs != 1 {
+        t.Errorf("Expected r to re-run once, got %d runs", runs)
+    }
+    if _, ok := linted.files["/gone.py"]; ok {
+        t.Error("Expected r to forget the last results")
+    }
+}
+
+func TestVerbose(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    var out bytes.Buffer
+    log.SetOutput(&out)
+    defer log.SetOutput(os.Stderr)
+
+    debugf("hidden %d", 1)
+    if out.Len() > 0 {
+        t.Errorf("Expected nothing logged without -v, got %q", out.String())
+    }
+    config.Verbose = true
+    debugf("shown %d", 2)
+    if !strings.Contains(out.String(), "shown 2") {
+        t.Errorf("Expected the diagnostic with -v, got %q", out.String())
+    }
+}
+
+func TestProfile(t *testing.T) {
+    summary := Summary{}
+    for _, duration := range []time.Duration{3 * time.Second, 1200 * time.Millisecond} {
+        tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+        tf.Linters = []LinterStatus{
+            {Name: "pylint", Ran: true},
+            {Name: "govet", Ran: true},
+            {Name: "golint", Reason: "not installed"},
+        }
+        tf.timed("pylint", duration)
+        tf.timed("govet", 150*time.Millisecond)
+        summary.Add(tf)
+    }
+    if got := summary.Profile(); got != "pylint 4.2s, govet 300ms" {
+        t.Errorf("Unexpected profile %q", got)
+    }
+}
+
+func TestTrend(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.NoColor = true
+    lastRun.total, lastRun.done = 0, false
+    defer func() { lastRun.total, lastRun.done = 0, false }()
+    var trends []string
+    for _, total := range []int{5, 2, 2, 4} {
+        summary := Summary{Warnings: total}
+        summary.compareLastRun()
+        trends = append(trends, summary.Trend())
+    }
+    if got := strings.Join(trends, "|"); got != "|▼ 3 fewer||▲ 2 new" {
+        t.Errorf("Unexpected trends %q", got)
+    }
+}
+
+func TestConfigFile(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    dir, _ = filepath.EvalSymlinks(dir)
+    nested := filepath.Join(dir, "a", "b")
+    if err := os.MkdirAll(nested, 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(filepath.Join(dir, configFileName), []byte(`{"jobs": 4, "base": "main"}`), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if found := findConfigFile(nested); found != filepath.Join(dir, configFileName) {
+        t.Errorf("Expected to find the settings file above %s, got %q", nested, found)
+    }
+
+    // Flags passed on the command line beat the file
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    oldFlags := flag.CommandLine
+    defer func() { flag.CommandLine = oldFlags }()
+    flag.CommandLine = flag.NewFlagSet("lintblame", flag.ContinueOnError)
+    flag.IntVar(&config.Jobs, "jobs", 1, "")
+    flag.StringVar(&config.BaseBranch, "base", "", "")
+    if err := flag.CommandLine.Parse([]string{"-base", "dev"}); err != nil {
+        t.Fatal(err)
+    }
+    loadConfigFile(nested, map[string]bool{"base": true})
+    if config.Jobs != 4 {
+        t.Errorf("Expected the file to set -jobs to 4, got %d", config.Jobs)
+    }
+    if config.BaseBranch != "dev" {
+        t.Errorf("Expected the passed -base to win over the file, got %q", config.BaseBranch)
+    }
+
+    var settings map[string]interface{}
+    decoder := json.NewDecoder(strings.NewReader(`{"jobs": 4, "linters": ["pylint", "gobuild"], "linter-exit-codes": {"pylint": [0, 4], "pep8": [0]}}`))
+    decoder.UseNumber()
+    if err := decoder.Decode(&settings); err != nil {
+        t.Fatal(err)
+    }
+    for name, expected := range map[string]string{
+        "jobs":              "4",
+        "linters":           "pylint,gobuild",
+        "linter-exit-codes": "pep8=0,pylint=0:4",
+    } {
+        if got := flagValue(settings[name]); got != expected {
+            t.Errorf("Expected %s to become %q, got %q", name, expected, got)
+        }
+    }
+}
+
+// Put a stand-in linter that prints output and exits with status on the
+// PATH, returning a func that takes it off again
+func fakeLinter(t *testing.T, dir string, name string, output string, status int) func() {
+    if _, err := exec.LookPath("sh"); err != nil {
+        t.Skip("sh not installed")
+    }
+    script := fmt.Sprintf("#!/bin/sh\necho '%s'\nexit %d\n", output, status)
+    if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+        t.Fatal(err)
+    }
+    oldPath := os.Getenv("PATH")
+    os.Setenv("PATH", dir+string(os.PathListSeparator)+oldPath)
+    forget := func() {
+        binaries.Lock()
+        delete(binaries.found, name)
+        binaries.Unlock()
+    }
+    forget()
+    return func() {
+        os.Setenv("PATH", oldPath)
+        forget()
+    }
+}
+
+func TestRegexLinter(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    defer fakeLinter(t, dir, "house-lint", "3:5 H001 no tabs\n7 something else\nx:1 H002 not a line", 1)()
+
+    spec := customLinterSpec{
+        Name:    "house-style",
+        Command: []string{"house-lint", "--strict", "{file}"},
+        Exts:    []string{".py"},
+        Pattern: `^(?P<line>\S+?)(?::(?P<col>\d+))? (?:(?P<code>H\d+) )?(?P<message>.+)$`,
+    }
+    linter, err := newRegexLinter(spec)
+    if err != nil {
+        t.Fatal(err)
+    }
+    file := filepath.Join(dir, "app.py")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    linter.Run(tf)
+    if len(tf.Warts) != 2 || len(tf.Warts[3]) != 1 || len(tf.Warts[7]) != 1 {
+        t.Fatalf("Expected warts on lines 3 and 7, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[3][0]; wart.Reporter != "house-style" || wart.Column != 5 || wart.IssueCode != "H001" || wart.Message != "no tabs" {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if wart := tf.Warts[7][0]; wart.Column != 0 || wart.IssueCode != "-" || wart.Message != "something else" {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+
+    for _, bad := range []customLinterSpec{
+        {Name: "pylint", Command: []string{"x"}, Exts: []string{".py"}, Pattern: `(?P<line>\d+) (?P<message>.+)`},
+        {Name: "nolines", Command: []string{"x"}, Exts: []string{".py"}, Pattern: `(?P<message>.+)`},
+        {Name: "nocommand", Exts: []string{".py"}, Pattern: `(?P<line>\d+) (?P<message>.+)`},
+    } {
+        if _, err := newRegexLinter(bad); err == nil {
+            t.Errorf("Expected %s to be rejected", bad.Name)
+        }
+    }
+}
+
+func TestGoSecReport(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    // A stand-in gosec on the PATH
+    report := `{"Issues": [` +
+        `{"severity": "MEDIUM", "rule_id": "G104", "details": "Errors unhandled.", "file": "` + dir + `/main.go", "line": "3", "column": "2"},` +
+        `{"severity": "HIGH", "rule_id": "G204", "details": "Subprocess launched with variable", "file": "` + dir + `/main.go", "line": "5-7", "column": "1"},` +
+        `{"severity": "LOW", "rule_id": "G101", "details": "Elsewhere", "file": "` + dir + `/other.go", "line": "1", "column": "1"}]}`
+    defer fakeLinter(t, dir, "gosec", report, 1)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"gosec": true}
+    file := filepath.Join(dir, "main.go")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.GoSec()
+    if len(tf.Warts) != 2 || len(tf.Warts[3]) != 1 || len(tf.Warts[5]) != 1 {
+        t.Fatalf("Expected warts on lines 3 and 5, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[5][0]; wart.IssueCode != "G204" || wart.Message != "Subprocess launched with variable (severity HIGH)" {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if severity := tf.Warts[5][0].Severity; severity != SeverityError {
+        t.Errorf("Expected HIGH to be an error, got %s", severity)
+    }
+}
+
+func TestMaxLineLength(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    defer fakeLinter(t, dir, "pep8", "", 0)()
+    // Report the arguments it was given as the message
+    script := "#!/bin/sh\necho \"x.py:1:1: E501 $*\"\n"
+    if err := ioutil.WriteFile(filepath.Join(dir, "pep8"), []byte(script), 0755); err != nil {
+        t.Fatal(err)
+    }
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    if args := config.lineLengthArgs("--max-line-length"); len(args) != 0 {
+        t.Errorf("Expected no flag by default, got %v", args)
+    }
+    config.MaxLineLength = 100
+    config.Linters = map[string]bool{"pep8": true}
+    file := filepath.Join(dir, "x.py")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.Pep8()
+    if len(tf.Warts[1]) != 1 || tf.Warts[1][0].Message != "--max-line-length=100 "+file {
+        t.Errorf("Expected the limit to be passed to pep8, got %v", tf.Warts)
+    }
+}
+
+func TestGoTestFailures(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    output := strings.Join([]string{
+        "--- FAIL: TestParse (0.00s)",
+        "    parse_test.go:12: got 2, want 1",
+        "    --- FAIL: TestParse/empty (0.00s)",
+        "        parse_test.go:20: unexpected error",
+        "            with more detail",
+        "--- FAIL: TestOther (0.00s)",
+        "    other_test.go:3: not this file",
+        "FAIL",
+        "FAIL\texample.com/parse\t0.002s",
+    }, "\n")
+    defer fakeLinter(t, dir, "go", output, 1)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"gotest": true}
+    file := filepath.Join(dir, "parse_test.go")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.GoTest()
+    if len(tf.Warts) != 2 || len(tf.Warts[12]) != 1 || len(tf.Warts[20]) != 1 {
+        t.Fatalf("Expected warts on lines 12 and 20, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[12][0]; wart.Reporter != "go test" || wart.IssueCode != "TestParse" || wart.Message != "got 2, want 1" || wart.Severity != SeverityError {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if wart := tf.Warts[20][0]; wart.IssueCode != "TestParse/empty" {
+        t.Errorf("Expected the subtest to be named, got %v", wart)
+    }
+}
+
+func TestGolangciReport(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    report := `{"Issues": [` +
+        `{"FromLinter": "staticcheck", "Text": "SA4006: this value of err is never used", "Pos": {"Filename": "main.go", "Line": 7, "Column": 2}},` +
+        `{"FromLinter": "errcheck", "Text": "Error return value of ` + "`f.Close`" + ` is not checked", "Severity": "error", "Pos": {"Filename": "main.go", "Line": 9, "Column": 9}},` +
+        `{"FromLinter": "typecheck", "Text": "undefined: x", "Pos": {"Filename": "main.go", "Line": 11, "Column": 5}},` +
+        `{"FromLinter": "unused", "Text": "func helper is unused", "Pos": {"Filename": "other.go", "Line": 3, "Column": 6}}]}`
+    defer fakeLinter(t, dir, "golangci-lint", report, 1)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"golangci-lint": true}
+    file := filepath.Join(dir, "main.go")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.GolangciLint()
+    if len(tf.Warts) != 3 || len(tf.Warts[7]) != 1 || len(tf.Warts[9]) != 1 || len(tf.Warts[11]) != 1 {
+        t.Fatalf("Expected warts on lines 7, 9 and 11, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[7][0]; wart.Reporter != "staticcheck" || wart.IssueCode != "SA4006" || wart.Message != "this value of err is never used" || wart.Column != 2 {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if wart := tf.Warts[9][0]; wart.IssueCode != "-" || wart.Severity != SeverityError {
+        t.Errorf("Expected an uncoded error, got %v", wart)
+    }
+    if wart := tf.Warts[11][0]; wart.IssueCode != "-" || wart.Message != "undefined: x" || wart.Severity != SeverityError {
+        t.Errorf("Expected typecheck's message to be left whole, got %v", wart)
+    }
+}
+
+func TestRubocopReport(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    report := `{"files": [{"path": "app.rb", "offenses": [` +
+        `{"severity": "convention", "message": "Prefer single-quoted strings.", "cop_name": "Style/StringLiterals", "location": {"start_line": 2, "line": 2, "column": 8}},` +
+        `{"severity": "warning", "message": "Useless assignment to variable - x.", "cop_name": "Lint/UselessAssignment", "location": {"line": 5, "column": 3}},` +
+        `{"severity": "fatal", "message": "unexpected token kEND", "cop_name": "Lint/Syntax", "location": {"line": 9, "column": 1}}]}]}`
+    defer fakeLinter(t, dir, "rubocop", report, 1)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"rubocop": true}
+    file := filepath.Join(dir, "app.rb")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.Rubocop()
+    if len(tf.Warts) != 3 {
+        t.Fatalf("Expected warts on 3 lines, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[2][0]; wart.Reporter != "rubocop" || wart.IssueCode != "Style/StringLiterals" || wart.Column != 8 || wart.Severity != SeverityInfo {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if wart := tf.Warts[5][0]; wart.Severity != SeverityWarning {
+        t.Errorf("Expected a warning, got %v", wart)
+    }
+    if wart := tf.Warts[9][0]; wart.Severity != SeverityError {
+        t.Errorf("Expected fatal to be an error, got %v", wart)
+    }
+}
+
+func TestBanditReport(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    report := `{"errors": [], "results": [` +
+        `{"test_id": "B602", "issue_severity": "HIGH", "issue_text": "subprocess call with shell=True", "line_number": 4, "col_offset": 0},` +
+        `{"test_id": "B101", "issue_severity": "LOW", "issue_text": "Use of assert detected.", "line_number": 7}]}`
+    defer fakeLinter(t, dir, "bandit", report, 1)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"bandit": true}
+    file := filepath.Join(dir, "app.py")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.Bandit()
+    if len(tf.Warts) != 2 || len(tf.Warts[4]) != 1 || len(tf.Warts[7]) != 1 {
+        t.Fatalf("Expected warts on lines 4 and 7, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[4][0]; wart.IssueCode != "B602" || wart.Message != "subprocess call with shell=True (severity HIGH)" || wart.Column != 1 {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if severity := tf.Warts[4][0].Severity; severity != SeverityError {
+        t.Errorf("Expected HIGH to be an error, got %s", severity)
+    }
+    if wart := tf.Warts[7][0]; wart.Column != 0 || wart.Severity != SeverityInfo {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+}
+
+func TestESLintReport(t *testing.T) {
+    dir, err := ioutil.TempDir("", "lintblame")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    report := `[{"filePath": "` + dir + `/app.ts", "messages": [` +
+        `{"ruleId": "no-unused-vars", "severity": 2, "message": "x is unused.", "line": 2, "column": 7},` +
+        `{"ruleId": "eqeqeq", "severity": 1, "message": "Expected ===.", "line": 4, "column": 9},` +
+        `{"ruleId": null, "severity": 2, "fatal": true, "message": "Parsing error", "line": 9, "column": 1}]}]`
+    defer fakeLinter(t, dir, "eslint", report, 1)()
+
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    config.Linters = map[string]bool{"eslint": true}
+    file := filepath.Join(dir, "app.ts")
+    tf := &TargetFile{config: &config, Path: file, LintPath: file, Warts: make(map[int][]Wart)}
+    tf.ESLint()
+    if len(tf.Warts) != 3 {
+        t.Fatalf("Expected warts on 3 lines, got %v", tf.Warts)
+    }
+    if wart := tf.Warts[2][0]; wart.IssueCode != "no-unused-vars" || wart.Column != 7 || wart.Severity != SeverityError {
+        t.Errorf("Unexpected wart %v", wart)
+    }
+    if wart := tf.Warts[4][0]; wart.Severity != SeverityWarning {
+        t.Errorf("Expected a warning, got %v", wart)
+    }
+    if wart := tf.Warts[9][0]; wart.IssueCode != "-" || wart.Severity != SeverityError {
+        t.Errorf("Expected a parse error, got %v", wart)
+    }
+}
+
+func TestSeverity(t *testing.T) {
+    cases := []struct {
+        reporter string
+        code     string
+        expected Severity
+    }{
+        {"build", "", SeverityError},
+        {"Pylint", "E0602", SeverityError},
+        {"Pylint", "F0001", SeverityError},
+        {"Pylint", "W0611", SeverityWarning},
+        {"Pylint", "C0111", SeverityInfo},
+        {"Pylint", "R0201", SeverityInfo},
+        {"PEP8", "E501", SeverityInfo},
+        {"PEP8", "E999", SeverityError},
+        {"flake8", "F821", SeverityError},
+        {"flake8", "F401", SeverityWarning},
+        {"flake8", "W291", SeverityInfo},
+        {"vet", "printf", SeverityWarning},
+        {"staticcheck", "SA4006", SeverityWarning},
+        {"staticcheck", "ST1005", SeverityInfo},
+        {"gofmt", "gofmt", SeverityInfo},
+    }
+    for _, c := range cases {
+        if got := mustWart(t, c.reporter, "1", "0", c.code, "").Severity; got != c.expected {
+            t.Errorf("Expected %s %s to be %s, got %s", c.reporter, c.code, c.expected, got)
+        }
+    }
+}
+
+func TestDuplicateWarts(t *testing.T) {
+    tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    tf.AddWart(mustWart(t, "vet", "4", "2", "-", "declared and not used: x"))
+    tf.AddWart(mustWart(t, "vet", "4", "2", "-", "declared and not used: x"))
+    tf.AddWart(mustWart(t, "vet", "4", "9", "-", "declared and not used: x"))
+    tf.AddWart(mustWart(t, "build", "4", "2", "-", "declared and not used: x"))
+    tf.AddWart(mustWart(t, "flake8", "7", "80", "E501", "line too long (82 > 79 characters)"))
+    tf.AddWart(mustWart(t, "PEP8", "7", "80", "E501", "line too long (82 > 79 characters)"))
+    if len(tf.Warts[4]) != 2 || tf.Warts[4][0].Column != 2 || tf.Warts[4][1].Column != 9 {
+        t.Errorf("Expected one wart for each column on line 4, got %v", tf.Warts[4])
+    }
+    if wart := tf.Warts[4][0]; wart.Reporter != "build" || wart.Severity != SeverityError {
+        t.Errorf("Expected the build error to replace vet's warning, got %v", wart)
+    }
+    if len(tf.Warts[7]) != 1 || tf.Warts[7][0].Reporter != "flake8" {
+        t.Errorf("Expected a single E501 on line 7, got %v", tf.Warts[7])
+    }
+}
+
+func TestSeverityMin(t *testing.T) {
+    oldConfig := config
+    defer func() { config = oldConfig }()
+    tf := &TargetFile{config: &config, Warts: make(map[int][]Wart)}
+    tf.AddWart(mustWart(t, "gofmt", "1", "0", "gofmt", "not formatted"))
+    tf.AddWart(mustWart(t, "vet", "2", "0", "printf", "bad verb"))
+    tf.AddWart(mustWart(t, "build", "2", "0", "", "undefined: x"))
+
+    config.SeverityMin = SeverityWarning
+    warts := filterWarts(tf)
+    if len(warts) != 1 || len(warts[2]) != 2 {
+        t.Errorf("Expected both line 2 warts, got %v", warts)
+    }
+    config.SeverityMin = SeverityError
+    warts = filterWarts(tf)
+    if len(warts) != 1 || len(warts[2]) != 1 || warts[2][0].Reporter != "build" {
+        t.Errorf("Expected just the build error, got %v", warts)
+    }
+}
@@ -0,0 +1,254 @@
+package lintblame
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A tool that checks target files. Adding one is a matter of adding it to
+// the registry below.
+type Linter interface {
+	Name() string   // As -linters and the per-linter flags know it
+	Binary() string // What has to be on PATH for it to run
+	Applies(ext string) bool
+	Run(tf *TargetFile) // Adds what it finds with tf.AddWart
+}
+
+// A linter that only applies to some of the files with its extensions
+type fileFilter interface {
+	AppliesTo(path string) bool
+}
+
+// A linter with its own TargetFile method for running it and parsing the
+// results
+type builtinLinter struct {
+	name   string
+	binary string
+	exts   []string
+	run    func(tf *TargetFile)
+}
+
+func (l builtinLinter) Name() string       { return l.name }
+func (l builtinLinter) Binary() string     { return l.binary }
+func (l builtinLinter) Run(tf *TargetFile) { l.run(tf) }
+func (l builtinLinter) Applies(ext string) bool {
+	return hasExt(l.exts, ext)
+}
+
+// `go test` runs the whole package's tests and puts failures on the lines
+// that logged them, which are in test files, so it only runs for those
+type goTestLinter struct {
+	builtinLinter
+}
+
+func (l goTestLinter) AppliesTo(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// A formatter's check mode, which prints something, usually a diff or the
+// file's name, when it would reformat the file. Any output is a wart at the
+// top of the file.
+type formatCheck struct {
+	name       string
+	binary     string
+	exts       []string
+	args       []string
+	message    string
+	lineLength string // Its flag for -max-line-length, if it has one
+}
+
+func (f formatCheck) Name() string   { return f.name }
+func (f formatCheck) Binary() string { return f.binary }
+func (f formatCheck) Applies(ext string) bool {
+	return hasExt(f.exts, ext)
+}
+
+func (f formatCheck) Run(tf *TargetFile) {
+	args := append(append([]string{}, f.args...), tf.config.lineLengthArgs(f.lineLength)...)
+	cmd := tf.command(f.binary, append(args, tf.LintPath)...)
+	results := tf.runLinter(f.name, cmd, stdoutStream)
+	if len(strings.TrimSpace(results)) > 0 {
+		if wart, ok := tf.parseWart(f.name, "1", "0", "-", f.message); ok {
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// A linter defined in the settings file's "custom-linters", whose output
+// is parsed with a regex
+type regexLinter struct {
+	name    string
+	command []string // `{file}` is replaced with the file's path
+	exts    []string
+	pattern *regexp.Regexp
+}
+
+// The settings file's description of a regexLinter
+type customLinterSpec struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	Exts    []string `json:"exts"`
+	Pattern string   `json:"pattern"` // With named groups line, col, code and message
+}
+
+func newRegexLinter(spec customLinterSpec) (regexLinter, error) {
+	if len(spec.Name) == 0 || len(spec.Command) == 0 || len(spec.Exts) == 0 {
+		return regexLinter{}, fmt.Errorf("custom linters need a name, command and exts")
+	} else if lookupLinter(spec.Name) != nil {
+		return regexLinter{}, fmt.Errorf("there's already a linter called %s", spec.Name)
+	}
+	// Patterns match a line at a time
+	pattern, err := regexp.Compile("(?m)" + spec.Pattern)
+	if err != nil {
+		return regexLinter{}, fmt.Errorf("bad pattern for %s: %s", spec.Name, err)
+	} else if pattern.SubexpIndex("line") < 0 || pattern.SubexpIndex("message") < 0 {
+		return regexLinter{}, fmt.Errorf("the pattern for %s needs line and message groups", spec.Name)
+	}
+	return regexLinter{spec.Name, spec.Command, spec.Exts, pattern}, nil
+}
+
+func (l regexLinter) Name() string   { return l.name }
+func (l regexLinter) Binary() string { return l.command[0] }
+func (l regexLinter) Applies(ext string) bool {
+	return hasExt(l.exts, ext)
+}
+
+func (l regexLinter) Run(tf *TargetFile) {
+	args := make([]string, 0, len(l.command))
+	hasFile := false
+	for _, arg := range l.command[1:] {
+		if strings.Contains(arg, "{file}") {
+			arg = strings.Replace(arg, "{file}", tf.LintPath, -1)
+			hasFile = true
+		}
+		args = append(args, arg)
+	}
+	if !hasFile {
+		args = append(args, tf.LintPath)
+	}
+	results := tf.runLinter(l.name, tf.command(l.command[0], args...), stdoutStream)
+	for _, match := range l.pattern.FindAllStringSubmatch(results, -1) {
+		group := func(name string) string {
+			if i := l.pattern.SubexpIndex(name); i >= 0 {
+				return strings.TrimSpace(match[i])
+			}
+			return ""
+		}
+		line := group("line")
+		if _, err := strconv.Atoi(line); err != nil {
+			continue
+		}
+		column := group("col")
+		if _, err := strconv.Atoi(column); err != nil {
+			column = "0"
+		}
+		code := group("code")
+		if len(code) == 0 {
+			code = "-"
+		}
+		if wart, ok := tf.parseWart(l.name, line, column, code, group("message")); ok {
+			tf.AddWart(wart)
+		}
+	}
+}
+
+// The flag passing -max-line-length on to a tool, e.g.
+// `--max-line-length=100`, or nothing if it isn't set
+func (c *Config) lineLengthArgs(flag string) []string {
+	if c.MaxLineLength == 0 || len(flag) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s=%d", flag, c.MaxLineLength)}
+}
+
+func hasExt(exts []string, ext string) bool {
+	for _, e := range exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	pyExts = []string{".py"}
+	goExts = []string{".go"}
+	jsExts = []string{".js", ".ts"}
+	rbExts = []string{".rb"}
+)
+
+// Every linter, in the order they're started for each file
+var linters = []Linter{
+	builtinLinter{"pep8", "pep8", pyExts, (*TargetFile).Pep8},
+	builtinLinter{"pylint", "pylint", pyExts, (*TargetFile).PyLint},
+	builtinLinter{"flake8", "flake8", pyExts, (*TargetFile).Flake8},
+	builtinLinter{"pyflakes", "pyflakes", pyExts, (*TargetFile).PyFlakes},
+	builtinLinter{"mypy", "mypy", pyExts, (*TargetFile).MyPy},
+	builtinLinter{"bandit", "bandit", pyExts, (*TargetFile).Bandit},
+	builtinLinter{"gobuild", "go", goExts, (*TargetFile).GoBuild},
+	builtinLinter{"govet", "go", goExts, (*TargetFile).GoVet},
+	builtinLinter{"golint", "golint", goExts, (*TargetFile).GoLint},
+	formatCheck{"gofmt", "gofmt", goExts, []string{"-l"}, "file is not gofmt-formatted", ""},
+	builtinLinter{"staticcheck", "staticcheck", goExts, (*TargetFile).StaticCheck},
+	builtinLinter{"errcheck", "errcheck", goExts, (*TargetFile).ErrCheck},
+	builtinLinter{"gosec", "gosec", goExts, (*TargetFile).GoSec},
+	builtinLinter{"gocyclo", "gocyclo", goExts, (*TargetFile).GoCyclo},
+	builtinLinter{"ineffassign", "ineffassign", goExts, (*TargetFile).IneffAssign},
+	goTestLinter{builtinLinter{"gotest", "go", goExts, (*TargetFile).GoTest}},
+	builtinLinter{"golangci-lint", "golangci-lint", goExts, (*TargetFile).GolangciLint},
+	formatCheck{"black", "black", pyExts, []string{"--check", "--diff", "--quiet"}, "file is not black-formatted", "--line-length"},
+	formatCheck{"isort", "isort", pyExts, []string{"--check-only", "--diff", "--quiet"}, "imports are not isort-sorted", "--line-length"},
+	builtinLinter{"eslint", "eslint", jsExts, (*TargetFile).ESLint},
+	builtinLinter{"rubocop", "rubocop", rbExts, (*TargetFile).Rubocop},
+}
+
+// Whether any linter applies to files with the extension, which makes
+// them worth watching
+func lintableExt(ext string) bool {
+	for _, linter := range linters {
+		if linter.Applies(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// The registered linter with the given name, or nil
+func lookupLinter(name string) Linter {
+	for _, linter := range linters {
+		if linter.Name() == name {
+			return linter
+		}
+	}
+	return nil
+}
+
+// What linters tag their warts with, where it isn't their name
+var reporterNames = map[string]string{
+	"pep8":    "PEP8",
+	"pylint":  "Pylint",
+	"gobuild": "build",
+	"govet":   "vet",
+	"gotest":  "go test",
+}
+
+// Whether some linter tags its warts with the reporter, whatever the case.
+// golangci-lint tags them with whichever of its own linters found them, so
+// while it's enabled any reporter might be one of those.
+func (c *Config) knownReporter(reporter string) bool {
+	if strings.EqualFold(reporter, "lintblame") || c.Linters["golangci-lint"] {
+		return true
+	}
+	for _, linter := range linters {
+		name := linter.Name()
+		if alias, ok := reporterNames[name]; ok {
+			name = alias
+		}
+		if strings.EqualFold(name, reporter) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,446 @@
+package lintblame
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Totals for a run, for footers that other tools parse
+type Summary struct {
+	Files     int
+	Total     int  // Files we set out to lint
+	Truncated bool // Whether -deadline cut the run short
+	Errors    int
+	Warnings  int
+	Infos     int
+	Internal  int // Warts from lintblame itself, like linters falling over
+	Dirty     int // Files with warts
+	Reporters map[string]int
+	Missing   map[string]bool          // Enabled linters that aren't installed
+	Timings   map[string]time.Duration // Time spent in each linter, across files
+	Duration  time.Duration
+	Timestamp time.Time
+	Delta     int  // Change in warts since the last run
+	Compared  bool // Whether there was a last run to compare against
+}
+
+// Count the file's warts that pass the configured filters
+func (s *Summary) Add(tf *TargetFile) {
+	s.Files++
+	if s.Reporters == nil {
+		s.Reporters = make(map[string]int)
+	}
+	if s.Missing == nil {
+		s.Missing = make(map[string]bool)
+	}
+	if s.Timings == nil {
+		s.Timings = make(map[string]time.Duration)
+	}
+	for _, status := range tf.Linters {
+		if status.Reason == "not installed" {
+			s.Missing[status.Name] = true
+		}
+		if status.Ran {
+			s.Timings[status.Name] += status.Duration
+		}
+	}
+	lineWarts := filterWarts(tf)
+	if len(lineWarts) > 0 {
+		s.Dirty++
+	}
+	for _, warts := range lineWarts {
+		for _, wart := range warts {
+			s.Reporters[wart.Reporter]++
+			if wart.Reporter == "lintblame" {
+				s.Internal++
+			}
+			switch wart.Severity {
+			case SeverityError:
+				s.Errors++
+			case SeverityWarning:
+				s.Warnings++
+			default:
+				s.Infos++
+			}
+		}
+	}
+}
+
+// Warts of every severity
+func (s Summary) Warts() int {
+	return s.Errors + s.Warnings + s.Infos
+}
+
+// The counts -stats prints
+type stats struct {
+	Files int `json:"files"`
+	Clean int `json:"clean"`
+	Dirty int `json:"dirty"`
+	Warts int `json:"warts"`
+}
+
+// Print just the run's counts on one line, e.g. `12 files: 10 clean, 2
+// dirty, 5 warts`
+func printStats(w io.Writer, summary Summary) {
+	counts := stats{
+		Files: summary.Files,
+		Clean: summary.Files - summary.Dirty,
+		Dirty: summary.Dirty,
+		Warts: summary.Warts(),
+	}
+	switch config.Format {
+	case "json":
+		if err := json.NewEncoder(w).Encode(counts); err != nil {
+			fatal("Failed to write stats: ", err)
+		}
+	case "kv":
+		fmt.Fprintf(w, "lintblame: stats files=%d clean=%d dirty=%d warts=%d\n", counts.Files, counts.Clean, counts.Dirty, counts.Warts)
+	default:
+		fmt.Fprintf(w, "%d files: %d clean, %d dirty, %d warts\n", counts.Files, counts.Clean, counts.Dirty, counts.Warts)
+	}
+}
+
+// Print the file's warts as `lintblame: wart key=value` lines
+func printWartsKV(w io.Writer, tf *TargetFile) {
+	lineWarts := filterWarts(tf)
+	for _, line := range sortedLines(lineWarts) {
+		for _, wart := range lineWarts[line] {
+			fmt.Fprintf(
+				w,
+				"lintblame: wart file=%q line=%d column=%d reporter=%q code=%q severity=%s blame=%q message=%q\n",
+				config.displayPath(tf.Path),
+				wart.Line,
+				wart.Column,
+				wart.Reporter,
+				wart.IssueCode,
+				wart.Severity,
+				tf.BlameName(line),
+				wart.Message,
+			)
+		}
+	}
+}
+
+// A wart as -format json prints it
+type jsonWart struct {
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	Reporter  string `json:"reporter"`
+	IssueCode string `json:"issueCode"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	BlameName string `json:"blameName"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"` // RFC 3339
+}
+
+func jsonWarts(tf *TargetFile) []jsonWart {
+	warts := make([]jsonWart, 0)
+	lineWarts := filterWarts(tf)
+	for _, line := range sortedLines(lineWarts) {
+		blame, blamed := tf.BlameFor(line)
+		for _, wart := range lineWarts[line] {
+			jw := jsonWart{
+				Path:      config.displayPath(tf.Path),
+				Line:      wart.Line,
+				Column:    wart.Column,
+				Reporter:  wart.Reporter,
+				IssueCode: wart.IssueCode,
+				Severity:  wart.Severity.String(),
+				Message:   wart.Message,
+				BlameName: tf.BlameName(line),
+			}
+			if blamed && !blame.Uncommitted() {
+				jw.Commit = blame.Commit
+				jw.Date = blame.Date.Format(time.RFC3339)
+			}
+			warts = append(warts, jw)
+		}
+	}
+	return warts
+}
+
+// Wart counts by reporter, e.g. `PEP8: 12, vet: 2, total: 14 across 3 files`
+func (s Summary) ReporterCounts() string {
+	reporters := make([]string, 0, len(s.Reporters))
+	for reporter := range s.Reporters {
+		reporters = append(reporters, reporter)
+	}
+	sort.Strings(reporters)
+	parts := make([]string, 0, len(reporters)+1)
+	for _, reporter := range reporters {
+		parts = append(parts, fmt.Sprintf("%s: %d", reporter, s.Reporters[reporter]))
+	}
+	files := "files"
+	if s.Dirty == 1 {
+		files = "file"
+	}
+	parts = append(parts, fmt.Sprintf("total: %d across %d %s", s.Warts(), s.Dirty, files))
+	return strings.Join(parts, ", ")
+}
+
+// The last finished run's wart total, kept between runs while watching
+var lastRun = struct {
+	sync.Mutex
+	total int
+	done  bool
+}{}
+
+// Compare the run's wart total to the last run's, and remember it for the
+// next one
+func (s *Summary) compareLastRun() {
+	total := s.Warts()
+	lastRun.Lock()
+	defer lastRun.Unlock()
+	s.Delta, s.Compared = total-lastRun.total, lastRun.done
+	lastRun.total, lastRun.done = total, true
+}
+
+// How the wart total moved since the last run, e.g. `▼ 3 fewer`, or
+// nothing when it didn't
+func (s Summary) Trend() string {
+	switch {
+	case !s.Compared || s.Delta == 0:
+		return ""
+	case s.Delta < 0:
+		return color("green", fmt.Sprintf("▼ %d fewer", -s.Delta))
+	}
+	return color("red", fmt.Sprintf("▲ %d new", s.Delta))
+}
+
+// The enabled linters that were skipped for not being installed, in order
+func (s Summary) MissingLinters() []string {
+	missing := make([]string, 0, len(s.Missing))
+	for name := range s.Missing {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// The linters that ran, slowest first
+func (s Summary) SlowestLinters() []string {
+	names := make([]string, 0, len(s.Timings))
+	for name := range s.Timings {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Timings[names[i]] != s.Timings[names[j]] {
+			return s.Timings[names[i]] > s.Timings[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Time spent in each linter for -profile, slowest first, e.g. `pylint
+// 4.2s, staticcheck 1.1s, govet 300ms`
+func (s Summary) Profile() string {
+	parts := make([]string, 0, len(s.Timings))
+	for _, name := range s.SlowestLinters() {
+		parts = append(parts, fmt.Sprintf("%s %s", name, roundDuration(s.Timings[name])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Round to tenths of a second over a second, else to milliseconds
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(100 * time.Millisecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// Whether the format is a single document of every file's warts, printed
+// once they're all in, rather than output per file
+func documentFormat() bool {
+	return config.Format == "json" || config.Format == "sarif" || config.Format == "checkstyle"
+}
+
+// The run's totals, as -format json and SARIF's run properties carry them
+type jsonSummary struct {
+	Files     int      `json:"files"`
+	Total     int      `json:"total"` // Files we set out to lint
+	Truncated bool     `json:"truncated"`
+	Errors    int      `json:"errors"`
+	Warnings  int      `json:"warnings"`
+	Infos     int      `json:"infos"`
+	Internal  int      `json:"internal"`
+	Missing   []string `json:"missing,omitempty"`
+	Duration  int64    `json:"durationMs"`
+	Timestamp string   `json:"timestamp"` // RFC 3339, when the run started
+}
+
+func summaryJSON(summary Summary) jsonSummary {
+	return jsonSummary{
+		Files:     summary.Files,
+		Total:     summary.Total,
+		Truncated: summary.Truncated,
+		Errors:    summary.Errors,
+		Warnings:  summary.Warnings,
+		Infos:     summary.Infos,
+		Internal:  summary.Internal,
+		Missing:   summary.MissingLinters(),
+		Duration:  summary.Duration.Nanoseconds() / int64(time.Millisecond),
+		Timestamp: summary.Timestamp.Format(time.RFC3339),
+	}
+}
+
+// A run, as -format json prints it
+type jsonReport struct {
+	Warts   []jsonWart  `json:"warts"`
+	Summary jsonSummary `json:"summary"`
+}
+
+// Gather every file's warts and the run's totals into one document
+func jsonRun(blocks []renderedFile, summary Summary) jsonReport {
+	report := jsonReport{Warts: make([]jsonWart, 0), Summary: summaryJSON(summary)}
+	for _, block := range blocks {
+		report.Warts = append(report.Warts, block.warts...)
+	}
+	return report
+}
+
+// Print every file's warts and the run's totals as a single JSON object on
+// one line, so each run of a watch is a line of its own
+func printWartsJSON(blocks []renderedFile, summary Summary) {
+	if err := json.NewEncoder(os.Stdout).Encode(jsonRun(blocks, summary)); err != nil {
+		fatal("Failed to write JSON: ", err)
+	}
+}
+
+// A checkstyle report, as -format checkstyle prints it
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// Build a checkstyle report of the files, clean ones included
+func checkstyle(files []*TargetFile) checkstyleReport {
+	report := checkstyleReport{Version: "4.3", Files: make([]checkstyleFile, len(files))}
+	for i, tf := range files {
+		file := checkstyleFile{Name: config.displayPath(tf.Path)}
+		lineWarts := filterWarts(tf)
+		for _, line := range sortedLines(lineWarts) {
+			for _, wart := range lineWarts[line] {
+				message := wart.Message
+				if wart.IssueCode != "-" && len(wart.IssueCode) > 0 {
+					message = fmt.Sprintf("[%s] %s", wart.IssueCode, wart.Message)
+				}
+				file.Errors = append(file.Errors, checkstyleError{
+					Line:     wart.Line,
+					Column:   wart.Column,
+					Severity: wart.Severity.String(),
+					Message:  message,
+					Source:   wart.Reporter,
+				})
+			}
+		}
+		report.Files[i] = file
+	}
+	return report
+}
+
+// Print the files' warts as checkstyle XML
+func printWartsCheckstyle(blocks []renderedFile) {
+	files := make([]*TargetFile, len(blocks))
+	for i, block := range blocks {
+		files[i] = block.file
+	}
+	out, err := xml.MarshalIndent(checkstyle(files), "", "  ")
+	if err != nil {
+		fatal("Failed to write checkstyle XML: ", err)
+	}
+	fmt.Printf("%s%s\n", xml.Header, out)
+}
+
+// Print the footer for the configured format
+func printFooter(summary Summary) {
+	switch config.Format {
+	case "json", "sarif", "checkstyle":
+		// Anything after the document would stop stdout from parsing
+		if missing := summary.MissingLinters(); len(missing) > 0 {
+			log.Printf("%s not found; skipping", strings.Join(missing, ", "))
+		}
+		if config.Profile {
+			log.Print("profile: ", summary.Profile())
+		}
+	case "kv":
+		fmt.Printf(
+			"lintblame: files=%d errors=%d warnings=%d infos=%d duration=%dms\n",
+			summary.Files,
+			summary.Errors,
+			summary.Warnings,
+			summary.Infos,
+			summary.Duration.Nanoseconds()/int64(time.Millisecond),
+		)
+		if summary.Truncated {
+			fmt.Printf("lintblame: truncated files=%d total=%d\n", summary.Files, summary.Total)
+		}
+		for _, name := range summary.MissingLinters() {
+			fmt.Printf("lintblame: missing linter=%s\n", name)
+		}
+		if config.Profile {
+			for _, name := range summary.SlowestLinters() {
+				fmt.Printf("lintblame: profile linter=%s duration=%dms\n", name, summary.Timings[name].Nanoseconds()/int64(time.Millisecond))
+			}
+		}
+	default:
+		if summary.Truncated {
+			fmt.Println(color("red", fmt.Sprintf(
+				"[run truncated by -deadline %s: %d of %d files finished]",
+				config.Deadline,
+				summary.Files,
+				summary.Total,
+			)))
+		}
+		if missing := summary.MissingLinters(); len(missing) > 0 {
+			fmt.Println(color("yellow", fmt.Sprintf("[%s not found; skipping]", strings.Join(missing, ", "))))
+		}
+		if summary.Warts() > 0 {
+			fmt.Println(color("bold", summary.ReporterCounts()))
+		}
+		if config.Profile {
+			fmt.Println(color("dim", fmt.Sprintf("[linter time: %s]", summary.Profile())))
+		}
+		if config.NoFooter {
+			return
+		}
+		start := summary.Timestamp
+		trend := ""
+		if change := summary.Trend(); len(change) > 0 {
+			trend = ", " + change
+		}
+		fmt.Printf(
+			"[last ran at %d:%d:%d in %s%s]\n",
+			start.Hour(),
+			start.Minute(),
+			start.Second(),
+			summary.Duration,
+			trend,
+		)
+	}
+}
@@ -0,0 +1,173 @@
+package lintblame
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// The parts of a SARIF 2.1.0 log that -format sarif fills in
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+	Properties  jsonSummary       `json:"properties"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	ExitCode            int    `json:"exitCode"`
+	StartTimeUTC        string `json:"startTimeUtc"`
+	EndTimeUTC          string `json:"endTimeUtc"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIF's names for our severities
+var sarifLevels = map[Severity]string{
+	SeverityInfo:    "note",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// Where a file is, relative to the root of its repo when it's in one, which
+// is what code scanning expects
+func sarifArtifact(path string) sarifArtifactLocation {
+	if root := gitRootFor(path); len(root) > 0 {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: "%SRCROOT%"}
+		}
+	}
+	return sarifArtifactLocation{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()}
+}
+
+// A rule for each reporter and issue code, e.g. `Pylint/C` or just `vet`
+func sarifRuleID(wart Wart) string {
+	if wart.IssueCode == "-" || len(wart.IssueCode) == 0 {
+		return wart.Reporter
+	}
+	return wart.Reporter + "/" + wart.IssueCode
+}
+
+// Build a single-run SARIF log of the files' warts, with the run's totals
+// as its properties
+func sarifReport(files []*TargetFile, summary Summary) sarifLog {
+	results := make([]sarifResult, 0)
+	rules := make(map[string]bool)
+	for _, tf := range files {
+		artifact := sarifArtifact(tf.Path)
+		lineWarts := filterWarts(tf)
+		for _, line := range sortedLines(lineWarts) {
+			for _, wart := range lineWarts[line] {
+				ruleID := sarifRuleID(wart)
+				rules[ruleID] = true
+				results = append(results, sarifResult{
+					RuleID:  ruleID,
+					Level:   sarifLevels[wart.Severity],
+					Message: sarifMessage{Text: wart.Message},
+					Locations: []sarifLocation{{sarifPhysicalLocation{
+						ArtifactLocation: artifact,
+						Region:           sarifRegion{StartLine: wart.Line, StartColumn: wart.Column},
+					}}},
+					Properties: map[string]string{"blameName": tf.BlameName(line)},
+				})
+			}
+		}
+	}
+	ruleIDs := make([]string, 0, len(rules))
+	for id := range rules {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+	driver := sarifDriver{
+		Name:           "lintblame",
+		InformationURI: "https://github.com/harveyr/golintblame",
+		Rules:          make([]sarifRule, len(ruleIDs)),
+	}
+	for i, id := range ruleIDs {
+		driver.Rules[i] = sarifRule{ID: id}
+	}
+	start := summary.Timestamp.UTC()
+	status := exitStatus(summary)
+	invocation := sarifInvocation{
+		// Warts are findings, not the run failing
+		ExecutionSuccessful: status == 0 || status == exitWarts,
+		ExitCode:            status,
+		StartTimeUTC:        start.Format(time.RFC3339Nano),
+		EndTimeUTC:          start.Add(summary.Duration).Format(time.RFC3339Nano),
+	}
+	run := sarifRun{
+		Tool:        sarifTool{Driver: driver},
+		Invocations: []sarifInvocation{invocation},
+		Results:     results,
+		Properties:  summaryJSON(summary),
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// Print the files' warts and the run's totals as a SARIF log on one line
+func printWartsSARIF(blocks []renderedFile, summary Summary) {
+	files := make([]*TargetFile, len(blocks))
+	for i, block := range blocks {
+		files[i] = block.file
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(sarifReport(files, summary)); err != nil {
+		fatal("Failed to write SARIF: ", err)
+	}
+}
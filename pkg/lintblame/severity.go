@@ -0,0 +1,88 @@
+package lintblame
+
+import "strings"
+
+// How much a wart matters, least first
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota // Style nits
+	SeverityWarning                 // Likely bugs
+	SeverityError                   // The file is broken
+)
+
+var severityNames = []string{"info", "warning", "error"}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// The severity with the given name
+func parseSeverity(name string) (Severity, bool) {
+	for i, severityName := range severityNames {
+		if name == severityName {
+			return Severity(i), true
+		}
+	}
+	return SeverityInfo, false
+}
+
+// The color a severity is printed in
+func (s Severity) Color() string {
+	return []string{"blue", "yellow", "red"}[s]
+}
+
+// The severities of gosec's and bandit's HIGH/MEDIUM/LOW ratings
+var ratedSeverities = map[string]Severity{
+	"HIGH":   SeverityError,
+	"MEDIUM": SeverityWarning,
+	"LOW":    SeverityInfo,
+}
+
+// Guess a wart's severity from its reporter and issue code
+func severityFor(reporter string, issueCode string) Severity {
+	switch reporter {
+	case "build", "typecheck", "lintblame", "mypy", "go test":
+		return SeverityError
+	case "vet", "errcheck", "ineffassign":
+		return SeverityWarning
+	case "golint", "gofmt", "gocyclo", "black", "isort":
+		return SeverityInfo
+	case "Pylint":
+		// Pylint's message ids start with their category, e.g. C0111
+		switch {
+		case strings.HasPrefix(issueCode, "E"), strings.HasPrefix(issueCode, "F"):
+			return SeverityError
+		case strings.HasPrefix(issueCode, "W"):
+			return SeverityWarning
+		}
+		return SeverityInfo
+	case "PEP8":
+		// E9 is for files that don't parse
+		if strings.HasPrefix(issueCode, "E9") {
+			return SeverityError
+		}
+		return SeverityInfo
+	case "flake8":
+		// The codes flake8's docs suggest failing builds on: syntax errors,
+		// undefined names, and the like. The rest of pyflakes' F codes are
+		// probable bugs, and everything else is style.
+		for _, prefix := range []string{"E9", "F63", "F7", "F82"} {
+			if strings.HasPrefix(issueCode, prefix) {
+				return SeverityError
+			}
+		}
+		if strings.HasPrefix(issueCode, "F") {
+			return SeverityWarning
+		}
+		return SeverityInfo
+	case "staticcheck":
+		// SA checks find bugs. S, ST and QF are simplifications, style, and
+		// quick fixes.
+		if strings.HasPrefix(issueCode, "SA") {
+			return SeverityWarning
+		}
+		return SeverityInfo
+	}
+	return SeverityWarning
+}
@@ -0,0 +1,364 @@
+package lintblame
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Posted to the screen whenever a lint run completes
+type resultsEvent struct {
+	tcell.EventTime
+	files    []*TargetFile
+	start    time.Time
+	duration time.Duration
+}
+
+// Posted to the screen for each line logged while it's up
+type logEvent struct {
+	tcell.EventTime
+	line string
+}
+
+// Takes log output while the screen is up, so linter failures and -v
+// diagnostics don't scribble over it. The latest line goes on the status
+// bar, and all of them are printed once the screen closes.
+type tuiLog struct {
+	screen tcell.Screen
+	lock   sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (l *tuiLog) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	l.buf.Write(p)
+	l.lock.Unlock()
+	ev := &logEvent{line: strings.TrimSpace(string(p))}
+	ev.SetEventNow()
+	// Dropped if the event queue is full; it's still in buf
+	l.screen.PostEvent(ev)
+	return len(p), nil
+}
+
+// Wart colors in the detail pane, by severity
+var severityColors = []tcell.Color{tcell.ColorBlue, tcell.ColorYellow, tcell.ColorRed}
+
+// A line of the detail pane
+type tuiLine struct {
+	text  string
+	style tcell.Style
+}
+
+type tui struct {
+	screen   tcell.Screen
+	files    []*TargetFile
+	selected int
+	scroll   int
+	filter   string   // Only show this reporter's warts, or all when empty
+	severity Severity // Only show warts at least this severe
+	status   string
+	logged   string // The latest log line
+}
+
+// Run every target file through the linters and wait for all of them
+func collectResults(modTimes ModifiedTimes) []*TargetFile {
+	filepaths := modTimes.SortaSorted()
+	files := receiveFiles(lintFiles(context.Background(), &config, filepaths), len(filepaths))
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// Lint and hand the results to the screen's event loop
+func postResults(screen tcell.Screen, modTimes ModifiedTimes) {
+	start := time.Now()
+	ev := &resultsEvent{files: collectResults(modTimes), start: start}
+	ev.duration = time.Now().Sub(start)
+	ev.SetEventNow()
+	screen.PostEvent(ev)
+}
+
+// Show results in a two-pane terminal UI until the user quits
+func runTUI(modTimes *ModifiedTimes) {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		fatal("Failed to open terminal: ", err)
+	}
+	if err := screen.Init(); err != nil {
+		fatal("Failed to initialize terminal: ", err)
+	}
+	logs := &tuiLog{screen: screen}
+	stderr := log.Writer()
+	log.SetOutput(logs)
+	defer func() {
+		screen.Fini()
+		log.SetOutput(stderr)
+		logs.lock.Lock()
+		os.Stderr.Write(logs.buf.Bytes())
+		logs.lock.Unlock()
+	}()
+
+	t := &tui{screen: screen, severity: config.SeverityMin, status: "linting..."}
+	go func() {
+		postResults(screen, *modTimes)
+		// The screen has its own keys
+		watch(modTimes, nil, func(m ModifiedTimes) { postResults(screen, m) })
+	}()
+
+	for {
+		t.draw()
+		switch ev := screen.PollEvent().(type) {
+		case *resultsEvent:
+			t.update(ev)
+		case *logEvent:
+			t.logged = ev.line
+		case *tcell.EventResize:
+			screen.Sync()
+		case *tcell.EventKey:
+			if !t.handleKey(ev) {
+				return
+			}
+		}
+	}
+}
+
+// Swap in a new set of results, keeping the same file selected if it's
+// still there
+func (t *tui) update(ev *resultsEvent) {
+	selectedPath := ""
+	if t.selected < len(t.files) {
+		selectedPath = t.files[t.selected].Path
+	}
+	t.files = ev.files
+	t.selected = 0
+	for i, tf := range t.files {
+		if tf.Path == selectedPath {
+			t.selected = i
+		}
+	}
+	t.status = fmt.Sprintf(
+		"last ran at %d:%d:%d in %s",
+		ev.start.Hour(),
+		ev.start.Minute(),
+		ev.start.Second(),
+		ev.duration,
+	)
+}
+
+// Returns false when the user asks to quit
+func (t *tui) handleKey(ev *tcell.EventKey) bool {
+	_, height := t.screen.Size()
+	page := height - 2
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		return false
+	case tcell.KeyUp:
+		t.selectFile(t.selected - 1)
+	case tcell.KeyDown:
+		t.selectFile(t.selected + 1)
+	case tcell.KeyPgUp:
+		t.scrollBy(-page)
+	case tcell.KeyPgDn:
+		t.scrollBy(page)
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return false
+		case 'k':
+			t.scrollBy(-1)
+		case 'j':
+			t.scrollBy(1)
+		case 'r':
+			t.cycleReporter()
+		case 's':
+			t.cycleSeverity()
+		}
+	}
+	return true
+}
+
+func (t *tui) selectFile(i int) {
+	if i < 0 || i >= len(t.files) {
+		return
+	}
+	t.selected = i
+	t.scroll = 0
+}
+
+func (t *tui) scrollBy(n int) {
+	t.scroll += n
+	if max := len(t.detailLines()) - 1; t.scroll > max {
+		t.scroll = max
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+}
+
+// Step the reporter filter through every reporter in the results, then
+// back to showing everything
+func (t *tui) cycleReporter() {
+	seen := make(map[string]bool)
+	for _, tf := range t.files {
+		for _, warts := range tf.Warts {
+			for _, wart := range warts {
+				seen[wart.Reporter] = true
+			}
+		}
+	}
+	reporters := []string{""}
+	for reporter := range seen {
+		reporters = append(reporters, reporter)
+	}
+	sort.Strings(reporters[1:])
+	next := 0
+	for i, reporter := range reporters {
+		if reporter == t.filter {
+			next = (i + 1) % len(reporters)
+		}
+	}
+	t.filter = reporters[next]
+	t.scroll = 0
+}
+
+// Step the minimum severity up to error, then back down to -severity-min
+func (t *tui) cycleSeverity() {
+	t.severity++
+	if t.severity > SeverityError {
+		t.severity = config.SeverityMin
+	}
+	t.scroll = 0
+}
+
+// The file's warts that pass the configured filters and the reporter and
+// severity filters
+func (t *tui) visibleWarts(tf *TargetFile) map[int][]Wart {
+	if t.filter == "" && t.severity <= config.SeverityMin {
+		return filterWarts(tf)
+	}
+	visible := make(map[int][]Wart)
+	for line, warts := range filterWarts(tf) {
+		for _, wart := range warts {
+			if (t.filter == "" || wart.Reporter == t.filter) && wart.Severity >= t.severity {
+				visible[line] = append(visible[line], wart)
+			}
+		}
+	}
+	return visible
+}
+
+// Render the selected file's warts for the detail pane
+func (t *tui) detailLines() []tuiLine {
+	lines := make([]tuiLine, 0)
+	if t.selected >= len(t.files) {
+		return lines
+	}
+	tf := t.files[t.selected]
+	plain := tcell.StyleDefault
+	lines = append(lines, tuiLine{config.displayPath(tf.Path), plain.Bold(true)})
+	visible := t.visibleWarts(tf)
+	if len(visible) == 0 {
+		return append(lines, tuiLine{"clean", plain.Foreground(tcell.ColorGreen)})
+	}
+	for _, line := range sortedLines(visible) {
+		blameName := tf.BlameName(line)
+		nameColor := tcell.ColorBlue
+		if config.isMe(blameName) {
+			nameColor = tcell.ColorYellow
+		}
+		blame := ""
+		if !config.NoBlame {
+			blame = fmt.Sprintf(" (%s)", tf.BlameLabel(line))
+		}
+		lines = append(lines, tuiLine{
+			fmt.Sprintf("%d:%s %s", line, blame, strings.TrimSpace(tf.ContentLine(line))),
+			plain.Foreground(nameColor),
+		})
+		for _, wart := range visible[line] {
+			lines = append(lines, tuiLine{
+				fmt.Sprintf("    [%s %s] %s", wart.Reporter, wart.IssueCode, wart.Detail()),
+				plain.Foreground(severityColors[wart.Severity]),
+			})
+		}
+	}
+	return lines
+}
+
+// Write text starting at x, clipped at maxX
+func (t *tui) print(x int, y int, maxX int, style tcell.Style, text string) {
+	for _, r := range strings.Replace(text, "\t", "    ", -1) {
+		if x >= maxX {
+			return
+		}
+		t.screen.SetContent(x, y, r, nil, style)
+		x++
+	}
+}
+
+func (t *tui) draw() {
+	t.screen.Clear()
+	width, height := t.screen.Size()
+	listWidth := width / 3
+	if listWidth > 40 {
+		listWidth = 40
+	}
+	plain := tcell.StyleDefault
+
+	// File list, scrolled to keep the selection on screen
+	offset := 0
+	if t.selected >= height-1 {
+		offset = t.selected - height + 2
+	}
+	for i := offset; i < len(t.files) && i-offset < height-1; i++ {
+		tf := t.files[i]
+		count := 0
+		for _, warts := range t.visibleWarts(tf) {
+			count += len(warts)
+		}
+		style := plain.Foreground(tcell.ColorGreen)
+		if count > 0 {
+			style = plain.Foreground(tcell.ColorYellow)
+		}
+		if i == t.selected {
+			style = style.Reverse(true)
+		}
+		label := fmt.Sprintf("%s (%d)", config.displayPath(tf.Path), count)
+		t.print(0, i-offset, listWidth-1, style, label)
+	}
+	for y := 0; y < height-1; y++ {
+		t.screen.SetContent(listWidth-1, y, tcell.RuneVLine, nil, plain)
+	}
+
+	// Detail pane
+	detail := t.detailLines()
+	for y := 0; y < height-1 && t.scroll+y < len(detail); y++ {
+		line := detail[t.scroll+y]
+		t.print(listWidth+1, y, width, line.style, line.text)
+	}
+
+	// Status bar
+	filter := "all"
+	if t.filter != "" {
+		filter = t.filter
+	}
+	ran := t.status
+	if len(t.logged) > 0 {
+		// Up front, where a narrow terminal won't cut it off
+		ran += " | " + t.logged
+	}
+	status := fmt.Sprintf(
+		" lintblame | %s | reporter: %s | severity: %s+ | up/down files, pgup/pgdn/j/k scroll, r reporter, s severity, q quit",
+		ran,
+		filter,
+		t.severity,
+	)
+	t.print(0, height-1, width, plain.Reverse(true), status+strings.Repeat(" ", width))
+	t.screen.Show()
+}